@@ -0,0 +1,132 @@
+package peas
+
+import (
+	"errors"
+	"sync"
+)
+
+type AliasRegistry interface {
+	RegisterAlias(name string, alias string) error
+	RemoveAlias(alias string)
+	IsAlias(name string) bool
+	GetAliases(name string) []string
+	CanonicalName(name string) string
+}
+
+type DefaultAliasRegistry struct {
+	aliases             map[string]string
+	aliasNames          []string
+	deprecationWarnings bool
+	logger              Logger
+	mu                  sync.RWMutex
+}
+
+func NewDefaultAliasRegistry() *DefaultAliasRegistry {
+	return &DefaultAliasRegistry{
+		aliases:    make(map[string]string, 0),
+		aliasNames: make([]string, 0),
+		logger:     NewDefaultLogger(),
+		mu:         sync.RWMutex{},
+	}
+}
+
+func (registry *DefaultAliasRegistry) SetDeprecationWarnings(enabled bool) {
+	registry.mu.Lock()
+	registry.deprecationWarnings = enabled
+	registry.mu.Unlock()
+}
+
+func (registry *DefaultAliasRegistry) SetLogger(logger Logger) {
+	registry.mu.Lock()
+	registry.logger = logger
+	registry.mu.Unlock()
+}
+
+func (registry *DefaultAliasRegistry) RegisterAlias(name string, alias string) error {
+	if name == "" || alias == "" {
+		return errors.New("name or alias must not be empty")
+	}
+
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+
+	if name == alias {
+		registry.removeAlias(alias)
+		return nil
+	}
+
+	if registeredName, ok := registry.aliases[alias]; ok {
+		if registeredName == name {
+			return nil
+		}
+		return errors.New("alias '" + alias + "' is already registered for '" + registeredName + "'")
+	}
+
+	if registry.resolveAlias(name) == alias {
+		return errors.New("alias '" + alias + "' for '" + name + "' cannot be registered, it causes a circular alias reference")
+	}
+
+	registry.aliases[alias] = name
+	registry.aliasNames = append(registry.aliasNames, alias)
+	return nil
+}
+
+func (registry *DefaultAliasRegistry) RemoveAlias(alias string) {
+	registry.mu.Lock()
+	registry.removeAlias(alias)
+	registry.mu.Unlock()
+}
+
+func (registry *DefaultAliasRegistry) removeAlias(alias string) {
+	if _, ok := registry.aliases[alias]; ok {
+		delete(registry.aliases, alias)
+		registry.aliasNames = removeString(registry.aliasNames, alias)
+	}
+}
+
+func (registry *DefaultAliasRegistry) IsAlias(name string) bool {
+	registry.mu.RLock()
+	defer registry.mu.RUnlock()
+	_, ok := registry.aliases[name]
+	return ok
+}
+
+func (registry *DefaultAliasRegistry) GetAliases(name string) []string {
+	registry.mu.RLock()
+	defer registry.mu.RUnlock()
+
+	aliases := make([]string, 0)
+	for _, alias := range registry.aliasNames {
+		for registeredName, ok := registry.aliases[alias]; ok; registeredName, ok = registry.aliases[registeredName] {
+			if registeredName == name {
+				aliases = append(aliases, alias)
+				break
+			}
+		}
+	}
+	return aliases
+}
+
+func (registry *DefaultAliasRegistry) CanonicalName(name string) string {
+	registry.mu.RLock()
+	canonicalName := registry.resolveAlias(name)
+	warn := registry.deprecationWarnings && canonicalName != name && registry.logger != nil
+	logger := registry.logger
+	registry.mu.RUnlock()
+
+	if warn {
+		logger.Warn("Pea '" + canonicalName + "' is fetched by using the deprecated alias '" + name + "'")
+	}
+	return canonicalName
+}
+
+func (registry *DefaultAliasRegistry) resolveAlias(name string) string {
+	canonicalName := name
+	for {
+		registeredName, ok := registry.aliases[canonicalName]
+		if !ok {
+			return canonicalName
+		}
+		canonicalName = registeredName
+	}
+}
@@ -0,0 +1,154 @@
+package peas
+
+import (
+	"github.com/procyon-projects/goo"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestDefaultAliasRegistry_RegisterAlias(t *testing.T) {
+	aliasRegistry := NewDefaultAliasRegistry()
+
+	err := aliasRegistry.RegisterAlias("userRepository", "userRepo")
+	assert.Nil(t, err)
+	err = aliasRegistry.RegisterAlias("userRepository", "userRepo")
+	assert.Nil(t, err)
+	err = aliasRegistry.RegisterAlias("userRepo", "legacyUserRepo")
+	assert.Nil(t, err)
+
+	assert.True(t, aliasRegistry.IsAlias("userRepo"))
+	assert.True(t, aliasRegistry.IsAlias("legacyUserRepo"))
+	assert.False(t, aliasRegistry.IsAlias("userRepository"))
+	assert.Equal(t, []string{"userRepo", "legacyUserRepo"}, aliasRegistry.GetAliases("userRepository"))
+	assert.Equal(t, []string{"legacyUserRepo"}, aliasRegistry.GetAliases("userRepo"))
+	assert.Equal(t, "userRepository", aliasRegistry.CanonicalName("legacyUserRepo"))
+	assert.Equal(t, "userRepository", aliasRegistry.CanonicalName("userRepository"))
+}
+
+func TestDefaultAliasRegistry_RegisterAliasWithEmptyNameOrAlias(t *testing.T) {
+	aliasRegistry := NewDefaultAliasRegistry()
+
+	err := aliasRegistry.RegisterAlias("", "userRepo")
+	assert.NotNil(t, err)
+	assert.Equal(t, "name or alias must not be empty", err.Error())
+
+	err = aliasRegistry.RegisterAlias("userRepository", "")
+	assert.NotNil(t, err)
+	assert.Equal(t, "name or alias must not be empty", err.Error())
+}
+
+func TestDefaultAliasRegistry_RegisterAliasForAnotherName(t *testing.T) {
+	aliasRegistry := NewDefaultAliasRegistry()
+
+	err := aliasRegistry.RegisterAlias("userRepository", "repository")
+	assert.Nil(t, err)
+	err = aliasRegistry.RegisterAlias("orderRepository", "repository")
+	assert.NotNil(t, err)
+	assert.Equal(t, "alias 'repository' is already registered for 'userRepository'", err.Error())
+}
+
+func TestDefaultAliasRegistry_RegisterAliasWithCycle(t *testing.T) {
+	aliasRegistry := NewDefaultAliasRegistry()
+
+	err := aliasRegistry.RegisterAlias("a", "b")
+	assert.Nil(t, err)
+	err = aliasRegistry.RegisterAlias("b", "c")
+	assert.Nil(t, err)
+	err = aliasRegistry.RegisterAlias("c", "a")
+	assert.NotNil(t, err)
+	assert.Equal(t, "alias 'a' for 'c' cannot be registered, it causes a circular alias reference", err.Error())
+	assert.Equal(t, "a", aliasRegistry.CanonicalName("c"))
+}
+
+func TestDefaultAliasRegistry_RemoveAlias(t *testing.T) {
+	aliasRegistry := NewDefaultAliasRegistry()
+
+	err := aliasRegistry.RegisterAlias("userRepository", "userRepo")
+	assert.Nil(t, err)
+	aliasRegistry.RemoveAlias("userRepo")
+	aliasRegistry.RemoveAlias("unknown")
+	assert.False(t, aliasRegistry.IsAlias("userRepo"))
+	assert.Empty(t, aliasRegistry.GetAliases("userRepository"))
+
+	err = aliasRegistry.RegisterAlias("userRepository", "userRepo")
+	assert.Nil(t, err)
+	err = aliasRegistry.RegisterAlias("userRepo", "userRepo")
+	assert.Nil(t, err)
+	assert.False(t, aliasRegistry.IsAlias("userRepo"))
+}
+
+func TestDefaultAliasRegistry_CanonicalNameWithDeprecationWarnings(t *testing.T) {
+	logger := &testLogger{}
+	aliasRegistry := NewDefaultAliasRegistry()
+	aliasRegistry.SetLogger(logger)
+
+	err := aliasRegistry.RegisterAlias("userRepository", "userRepo")
+	assert.Nil(t, err)
+	assert.Equal(t, "userRepository", aliasRegistry.CanonicalName("userRepo"))
+	assert.Empty(t, logger.warnings)
+
+	aliasRegistry.SetDeprecationWarnings(true)
+	assert.Equal(t, "userRepository", aliasRegistry.CanonicalName("userRepo"))
+	assert.Equal(t, "userRepository", aliasRegistry.CanonicalName("userRepository"))
+	assert.Equal(t, []string{"Pea 'userRepository' is fetched by using the deprecated alias 'userRepo'"}, logger.warnings)
+}
+
+func TestDefaultPeaDefinitionRegistry_RegisterAlias(t *testing.T) {
+	peaDefinitionRegistry := NewDefaultPeaDefinitionRegistry()
+	peaDefinition := NewSimplePeaDefinition(goo.GetType(testStruct{}))
+	peaDefinitionRegistry.RegisterPeaDefinition("testPea", peaDefinition)
+	peaDefinitionRegistry.RegisterPeaDefinition("testPea2", NewSimplePeaDefinition(goo.GetType(testStruct2{})))
+
+	err := peaDefinitionRegistry.RegisterAlias("testPea", "oldTestPea")
+	assert.Nil(t, err)
+	assert.True(t, peaDefinitionRegistry.ContainsPeaDefinition("oldTestPea"))
+	assert.Equal(t, peaDefinition, peaDefinitionRegistry.GetPeaDefinition("oldTestPea"))
+
+	err = peaDefinitionRegistry.RegisterAlias("testPea", "testPea2")
+	assert.NotNil(t, err)
+	assert.Equal(t, "alias 'testPea2' cannot be registered, there is already a pea definition with the same name", err.Error())
+}
+
+func TestDefaultPeaDefinitionRegistry_RegisterPeaDefinitionWithAliasName(t *testing.T) {
+	peaDefinitionRegistry := NewDefaultPeaDefinitionRegistry()
+	peaDefinition := NewSimplePeaDefinition(goo.GetType(testStruct{}))
+	peaDefinitionRegistry.RegisterPeaDefinition("testPea", peaDefinition)
+	peaDefinitionRegistry.RegisterAlias("testPea", "oldTestPea")
+
+	err := peaDefinitionRegistry.RegisterPeaDefinition("oldTestPea", NewSimplePeaDefinition(goo.GetType(testStruct2{})))
+	assert.NotNil(t, err)
+	assert.Equal(t, "pea definition 'oldTestPea' cannot be registered, there is already an alias with the same name", err.Error())
+	assert.Equal(t, []string{"testPea"}, peaDefinitionRegistry.GetPeaDefinitionNames())
+	assert.Equal(t, peaDefinition, peaDefinitionRegistry.GetPeaDefinition("oldTestPea"))
+}
+
+func TestDefaultPeaDefinitionRegistry_RemovePeaDefinitionByAlias(t *testing.T) {
+	peaDefinitionRegistry := NewDefaultPeaDefinitionRegistry()
+	peaDefinitionRegistry.RegisterPeaDefinition("testPea", NewSimplePeaDefinition(goo.GetType(testStruct{})))
+	peaDefinitionRegistry.RegisterAlias("testPea", "oldTestPea")
+
+	peaDefinitionRegistry.RemovePeaDefinition("oldTestPea")
+	assert.False(t, peaDefinitionRegistry.ContainsPeaDefinition("testPea"))
+	assert.Equal(t, []string{}, peaDefinitionRegistry.GetPeaDefinitionNames())
+}
+
+func TestDefaultPeaFactory_GetPeaByAlias(t *testing.T) {
+	logger := &testLogger{}
+	peaDefinitionRegistry := NewDefaultPeaDefinitionRegistry()
+	peaDefinitionRegistry.SetLogger(logger)
+	peaDefinitionRegistry.SetDeprecationWarnings(true)
+	peaFactory := NewDefaultPeaFactory(WithPeaDefinitionRegistry(peaDefinitionRegistry))
+
+	peaFactory.RegisterPeaDefinition("aPea", NewSimplePeaDefinition(goo.GetType(newAStruct)))
+	err := peaFactory.RegisterAlias("aPea", "oldAPea")
+	assert.Nil(t, err)
+
+	pea1, err := peaFactory.GetPea("oldAPea")
+	assert.Nil(t, err)
+	pea2, err := peaFactory.GetPeaByNameAndType("aPea", goo.GetType(aStruct{}))
+	assert.Nil(t, err)
+	assert.Equal(t, pea1, pea2)
+	assert.True(t, peaFactory.ContainsSharedPea("aPea"))
+	assert.False(t, peaFactory.ContainsSharedPea("oldAPea"))
+	assert.Equal(t, []string{"Pea 'aPea' is fetched by using the deprecated alias 'oldAPea'"}, logger.warnings)
+}
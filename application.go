@@ -0,0 +1,208 @@
+package peas
+
+import (
+	"context"
+	"errors"
+	"github.com/procyon-projects/goo"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+const DefaultShutdownTimeout = 30 * time.Second
+
+type Runner interface {
+	Run(ctx context.Context, args []string) error
+}
+
+type ApplicationOption func(application *Application)
+
+type Application struct {
+	factory            DefaultPeaFactory
+	arguments          []string
+	signals            []os.Signal
+	signalChannel      chan os.Signal
+	shutdownTimeout    time.Duration
+	registryProcessors []PeaDefinitionRegistryProcessor
+	factoryProcessors  []PeaFactoryProcessor
+}
+
+func NewApplication(factory DefaultPeaFactory, options ...ApplicationOption) *Application {
+	application := &Application{
+		factory:            factory,
+		arguments:          os.Args[1:],
+		signals:            []os.Signal{os.Interrupt, syscall.SIGTERM},
+		signalChannel:      make(chan os.Signal, 1),
+		shutdownTimeout:    DefaultShutdownTimeout,
+		registryProcessors: make([]PeaDefinitionRegistryProcessor, 0),
+		factoryProcessors:  make([]PeaFactoryProcessor, 0),
+	}
+
+	for _, option := range options {
+		option(application)
+	}
+
+	return application
+}
+
+func WithArguments(args ...string) ApplicationOption {
+	return func(application *Application) {
+		application.arguments = args
+	}
+}
+
+func WithShutdownSignals(signals ...os.Signal) ApplicationOption {
+	return func(application *Application) {
+		application.signals = signals
+	}
+}
+
+func WithShutdownTimeout(timeout time.Duration) ApplicationOption {
+	return func(application *Application) {
+		application.shutdownTimeout = timeout
+	}
+}
+
+func WithPeaDefinitionRegistryProcessors(processors ...PeaDefinitionRegistryProcessor) ApplicationOption {
+	return func(application *Application) {
+		application.registryProcessors = append(application.registryProcessors, processors...)
+	}
+}
+
+func WithPeaFactoryProcessors(processors ...PeaFactoryProcessor) ApplicationOption {
+	return func(application *Application) {
+		application.factoryProcessors = append(application.factoryProcessors, processors...)
+	}
+}
+
+func (application *Application) GetPeaFactory() DefaultPeaFactory {
+	return application.factory
+}
+
+// Run bootstraps the application, runs the runners and starts the lifecycle peas, then waits until the context is done
+// or a shutdown signal is received. A signal received while bootstrapping shuts the application down before the runners.
+func (application *Application) Run(ctx context.Context) error {
+	if len(application.signals) != 0 {
+		signal.Notify(application.signalChannel, application.signals...)
+		defer signal.Stop(application.signalChannel)
+	}
+
+	completed, err := application.bootstrap()
+	if err != nil {
+		application.shutdown()
+		return err
+	} else if !completed {
+		return application.shutdown()
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	err = application.runRunners(ctx)
+	if err == nil {
+		err = application.factory.Start(ctx)
+	}
+
+	if err == nil {
+		select {
+		case <-ctx.Done():
+		case <-application.signalChannel:
+		}
+	}
+
+	shutdownErr := application.shutdown()
+	if err != nil {
+		return err
+	}
+	return shutdownErr
+}
+
+// bootstrap runs the bootstrap steps in order and reports whether all of them are completed. If a shutdown signal
+// is received, the remaining steps are skipped.
+func (application *Application) bootstrap() (bool, error) {
+	steps := []func() error{
+		func() error {
+			for _, processor := range application.registryProcessors {
+				processor.AfterPeaDefinitionRegistryInitialization(application.factory)
+			}
+			return nil
+		},
+		func() error {
+			application.factory.EvaluateConditions()
+			return nil
+		},
+		func() error {
+			for _, processor := range application.factoryProcessors {
+				processor.AfterPeaFactoryInitialization(application.factory)
+			}
+			return nil
+		},
+		application.factory.PreInstantiateSharedPeas,
+	}
+
+	for _, step := range steps {
+		if application.isShutdownSignalReceived() {
+			return false, nil
+		}
+
+		if err := step(); err != nil {
+			return false, err
+		}
+	}
+	return !application.isShutdownSignalReceived(), nil
+}
+
+func (application *Application) isShutdownSignalReceived() bool {
+	select {
+	case <-application.signalChannel:
+		return true
+	default:
+		return false
+	}
+}
+
+func (application *Application) runRunners(ctx context.Context) error {
+	runnerType := goo.GetType((*Runner)(nil))
+	peaNames := application.factory.GetSharedPeaNamesByType(runnerType)
+	peaNames = application.factory.dependencies.sortByDependencies(peaNames)
+
+	for _, peaName := range peaNames {
+		runner, ok := application.factory.GetSharedPea(peaName).(Runner)
+		if !ok {
+			continue
+		}
+
+		err := runner.Run(ctx, application.arguments)
+		if err != nil {
+			return NewPeaPreparationError(peaName, "runner failed : "+err.Error())
+		}
+	}
+	return nil
+}
+
+func (application *Application) shutdown() error {
+	ctx := context.Background()
+	if application.shutdownTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, application.shutdownTimeout)
+		defer cancel()
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		err := application.factory.Stop(ctx)
+		destroyErr := application.factory.DestroySharedPeas()
+		if err == nil {
+			err = destroyErr
+		}
+		done <- err
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return errors.New("application could not be shut down in time : " + ctx.Err().Error())
+	}
+}
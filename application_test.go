@@ -0,0 +1,191 @@
+package peas
+
+import (
+	"context"
+	"errors"
+	"github.com/procyon-projects/goo"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"testing"
+	"time"
+)
+
+type testRunner struct {
+	events *lifecycleEvents
+	err    error
+}
+
+func (runner testRunner) Run(ctx context.Context, args []string) error {
+	runner.events.add("run:" + args[0])
+	return runner.err
+}
+
+type testDestroyablePea struct {
+	events *lifecycleEvents
+}
+
+func (pea testDestroyablePea) DestroyPea() error {
+	pea.events.add("destroy")
+	return nil
+}
+
+type testRegistryProcessor struct {
+	events *lifecycleEvents
+}
+
+func (processor testRegistryProcessor) AfterPeaDefinitionRegistryInitialization(registry PeaDefinitionRegistry) {
+	processor.events.add("registry")
+}
+
+type testFactoryProcessor struct {
+	events *lifecycleEvents
+}
+
+func (processor testFactoryProcessor) AfterPeaFactoryInitialization(factory ConfigurablePeaFactory) {
+	processor.events.add("factory")
+}
+
+func TestApplication_RunUntilContextIsCancelled(t *testing.T) {
+	events := &lifecycleEvents{}
+	ctx, cancel := context.WithCancel(context.Background())
+	peaFactory := NewDefaultPeaFactory()
+	peaFactory.RegisterSharedPea("runner", testRunner{events: events})
+	peaFactory.RegisterSharedPea("server", serverPea{&testLifecyclePea{name: "server", events: events}})
+	peaFactory.RegisterPeaDefinition("destroyable", NewSimplePeaDefinition(goo.GetType(func() testDestroyablePea {
+		events.add("create")
+		return testDestroyablePea{events}
+	})))
+
+	application := NewApplication(peaFactory,
+		WithArguments("test-arg"),
+		WithPeaDefinitionRegistryProcessors(testRegistryProcessor{events}),
+		WithPeaFactoryProcessors(testFactoryProcessor{events}),
+	)
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		cancel()
+	}()
+
+	err := application.Run(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"registry", "factory", "create", "run:test-arg", "start:server", "stop:server", "destroy"}, events.events)
+	assert.Equal(t, 0, peaFactory.GetSharedPeaCount())
+}
+
+func TestApplication_RunUntilSignalIsReceived(t *testing.T) {
+	events := &lifecycleEvents{}
+	peaFactory := NewDefaultPeaFactory()
+	peaFactory.RegisterSharedPea("server", serverPea{&testLifecyclePea{name: "server", events: events}})
+
+	application := NewApplication(peaFactory, WithShutdownSignals())
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		application.signalChannel <- os.Interrupt
+	}()
+
+	err := application.Run(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"start:server", "stop:server"}, events.events)
+}
+
+func TestApplication_RunWhenSignalIsReceivedWhileBootstrapping(t *testing.T) {
+	events := &lifecycleEvents{}
+	peaFactory := NewDefaultPeaFactory()
+	application := NewApplication(peaFactory, WithShutdownSignals(), WithArguments("test-arg"))
+
+	peaFactory.RegisterSharedPea("runner", testRunner{events: events})
+	peaFactory.RegisterSharedPea("server", serverPea{&testLifecyclePea{name: "server", events: events}})
+	peaFactory.RegisterPeaDefinition("destroyable", NewSimplePeaDefinition(goo.GetType(func() testDestroyablePea {
+		events.add("create")
+		application.signalChannel <- os.Interrupt
+		return testDestroyablePea{events}
+	})))
+
+	err := application.Run(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"create", "destroy"}, events.events)
+	assert.Equal(t, 0, peaFactory.GetSharedPeaCount())
+}
+
+func TestApplication_RunWhenRunnerFails(t *testing.T) {
+	events := &lifecycleEvents{}
+	peaFactory := NewDefaultPeaFactory()
+	peaFactory.RegisterSharedPea("runner", testRunner{events: events, err: errors.New("migration failed")})
+	peaFactory.RegisterSharedPea("server", serverPea{&testLifecyclePea{name: "server", events: events}})
+
+	application := NewApplication(peaFactory, WithArguments("test-arg"))
+	err := application.Run(context.Background())
+	assert.NotNil(t, err)
+	assert.Equal(t, "runner : runner failed : migration failed", err.Error())
+	assert.Equal(t, []string{"run:test-arg"}, events.events)
+}
+
+type slowDestroyablePea struct {
+}
+
+func (pea slowDestroyablePea) DestroyPea() error {
+	time.Sleep(time.Second)
+	return nil
+}
+
+func TestApplication_RunWithShutdownTimeout(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	peaFactory := NewDefaultPeaFactory()
+	peaFactory.RegisterSharedPea("slowPea", slowDestroyablePea{})
+
+	application := NewApplication(peaFactory, WithShutdownTimeout(10*time.Millisecond))
+	err := application.Run(ctx)
+	assert.NotNil(t, err)
+	assert.Equal(t, "application could not be shut down in time : context deadline exceeded", err.Error())
+}
+
+func TestApplication_RunEvaluatesConditions(t *testing.T) {
+	events := &lifecycleEvents{}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	peaFactory := NewDefaultPeaFactory()
+	peaFactory.RegisterPeaDefinition("inMemoryQueue", NewSimplePeaDefinition(goo.GetType(newInMemoryQueue),
+		WithConditionOnMissingPea(goo.GetType((*messageQueue)(nil)))))
+
+	application := NewApplication(peaFactory,
+		WithArguments("test-arg"),
+		WithPeaDefinitionRegistryProcessors(registryProcessorFunc(func(registry PeaDefinitionRegistry) {
+			events.add("registry")
+			registry.RegisterPeaDefinition("brokerQueue", NewSimplePeaDefinition(goo.GetType(newBrokerQueue)))
+		})))
+
+	err := application.Run(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"registry"}, events.events)
+	assert.Equal(t, []string{"brokerQueue"}, peaFactory.GetPeaDefinitionNames())
+
+	evaluation, ok := peaFactory.GetConditionEvaluationReport().GetEvaluation("inMemoryQueue")
+	assert.True(t, ok)
+	assert.False(t, evaluation.IsMatch())
+}
+
+type registryProcessorFunc func(registry PeaDefinitionRegistry)
+
+func (fun registryProcessorFunc) AfterPeaDefinitionRegistryInitialization(registry PeaDefinitionRegistry) {
+	fun(registry)
+}
+
+func TestApplication_RunWhenPreInstantiationFails(t *testing.T) {
+	events := &lifecycleEvents{}
+	peaFactory := NewDefaultPeaFactory()
+	peaFactory.RegisterPeaDefinition("destroyable", NewSimplePeaDefinition(goo.GetType(func() testDestroyablePea {
+		events.add("create")
+		return testDestroyablePea{events}
+	})))
+	peaFactory.RegisterPeaDefinition("failing", NewSimplePeaDefinition(goo.GetType(func() (*databasePea, error) {
+		return nil, errors.New("connection refused")
+	})))
+
+	application := NewApplication(peaFactory, WithArguments("test-arg"))
+	err := application.Run(context.Background())
+	assert.NotNil(t, err)
+	assert.Equal(t, []string{"create", "destroy"}, events.events)
+	assert.Equal(t, 0, peaFactory.GetSharedPeaCount())
+}
@@ -0,0 +1,278 @@
+package peas
+
+import (
+	"github.com/procyon-projects/goo"
+	"reflect"
+	"strconv"
+	"time"
+)
+
+type PeaDefinitionBuilder struct {
+	typ     goo.Type
+	typErr  error
+	name    string
+	named   bool
+	options []SimplePeaDefinitionOption
+}
+
+// Define starts building a pea definition for the given constructor function, struct instance or goo.Type.
+func Define(ctor interface{}) *PeaDefinitionBuilder {
+	builder := &PeaDefinitionBuilder{
+		options: make([]SimplePeaDefinitionOption, 0),
+	}
+
+	if typ, ok := ctor.(goo.Type); ok {
+		builder.typ = typ
+	} else if ctor != nil {
+		builder.typ, builder.typErr = getInstanceType(ctor)
+	}
+
+	return builder
+}
+
+func (builder *PeaDefinitionBuilder) Named(peaName string) *PeaDefinitionBuilder {
+	builder.name = peaName
+	builder.named = true
+	return builder
+}
+
+func (builder *PeaDefinitionBuilder) Scope(scope PeaScope) *PeaDefinitionBuilder {
+	builder.options = append(builder.options, WithScope(scope))
+	return builder
+}
+
+func (builder *PeaDefinitionBuilder) Primary() *PeaDefinitionBuilder {
+	builder.options = append(builder.options, WithPrimary())
+	return builder
+}
+
+func (builder *PeaDefinitionBuilder) Lazy() *PeaDefinitionBuilder {
+	builder.options = append(builder.options, WithLazyInit())
+	return builder
+}
+
+func (builder *PeaDefinitionBuilder) AutowireCandidate(candidate bool) *PeaDefinitionBuilder {
+	builder.options = append(builder.options, WithAutowireCandidate(candidate))
+	return builder
+}
+
+func (builder *PeaDefinitionBuilder) DependsOn(peaNames ...string) *PeaDefinitionBuilder {
+	builder.options = append(builder.options, WithDependsOn(peaNames...))
+	return builder
+}
+
+func (builder *PeaDefinitionBuilder) InitMethod(methodName string) *PeaDefinitionBuilder {
+	builder.options = append(builder.options, WithInitMethod(methodName))
+	return builder
+}
+
+func (builder *PeaDefinitionBuilder) DestroyMethod(methodName string) *PeaDefinitionBuilder {
+	builder.options = append(builder.options, WithDestroyMethod(methodName))
+	return builder
+}
+
+func (builder *PeaDefinitionBuilder) InitializationTimeout(timeout time.Duration) *PeaDefinitionBuilder {
+	builder.options = append(builder.options, WithInitializationTimeout(timeout))
+	return builder
+}
+
+func (builder *PeaDefinitionBuilder) Argument(index int, value interface{}) *PeaDefinitionBuilder {
+	builder.options = append(builder.options, WithArgument(index, value))
+	return builder
+}
+
+func (builder *PeaDefinitionBuilder) Exposes(types ...goo.Type) *PeaDefinitionBuilder {
+	builder.options = append(builder.options, WithExposedTypes(types...))
+	return builder
+}
+
+func (builder *PeaDefinitionBuilder) Parent(parentName string) *PeaDefinitionBuilder {
+	builder.options = append(builder.options, WithParent(parentName))
+	return builder
+}
+
+func (builder *PeaDefinitionBuilder) Abstract() *PeaDefinitionBuilder {
+	builder.options = append(builder.options, WithAbstract())
+	return builder
+}
+
+func (builder *PeaDefinitionBuilder) Tags(tags ...string) *PeaDefinitionBuilder {
+	builder.options = append(builder.options, WithTags(tags...))
+	return builder
+}
+
+func (builder *PeaDefinitionBuilder) Attribute(key string, value interface{}) *PeaDefinitionBuilder {
+	builder.options = append(builder.options, WithAttribute(key, value))
+	return builder
+}
+
+func (builder *PeaDefinitionBuilder) Description(description string) *PeaDefinitionBuilder {
+	builder.options = append(builder.options, WithDescription(description))
+	return builder
+}
+
+func (builder *PeaDefinitionBuilder) Role(role PeaRole) *PeaDefinitionBuilder {
+	builder.options = append(builder.options, WithRole(role))
+	return builder
+}
+
+func (builder *PeaDefinitionBuilder) When(conditions ...Condition) *PeaDefinitionBuilder {
+	builder.options = append(builder.options, WithConditions(conditions...))
+	return builder
+}
+
+func (builder *PeaDefinitionBuilder) Profiles(expressions ...string) *PeaDefinitionBuilder {
+	builder.options = append(builder.options, WithProfiles(expressions...))
+	return builder
+}
+
+func (builder *PeaDefinitionBuilder) Options(options ...SimplePeaDefinitionOption) *PeaDefinitionBuilder {
+	builder.options = append(builder.options, options...)
+	return builder
+}
+
+// Build validates the definition and returns all the problems found at once as PeaDefinitionValidationError.
+func (builder *PeaDefinitionBuilder) Build() (*SimplePeaDefinition, error) {
+	definition := NewSimplePeaDefinition(builder.typ, builder.options...)
+
+	problems := builder.validate(definition)
+	if len(problems) != 0 {
+		peaName := builder.name
+		if peaName == "" {
+			peaName = definition.GetTypeName()
+		}
+		return nil, NewPeaDefinitionValidationError(peaName, problems)
+	}
+
+	return definition, nil
+}
+
+// Register builds the definition and registers it into the given registry. If the pea is not named,
+// the name is generated by the registry. It returns the name which the pea is registered with.
+func (builder *PeaDefinitionBuilder) Register(registry PeaDefinitionRegistry) (string, error) {
+	if registry == nil {
+		return "", NewPeaDefinitionValidationError(builder.name, []string{"pea definition registry must not be nil"})
+	}
+
+	definition, err := builder.Build()
+	if err != nil {
+		return "", err
+	}
+
+	if !builder.named {
+		return registry.RegisterPeaDefinitionWithGeneratedName(definition)
+	}
+
+	return builder.name, registry.RegisterPeaDefinition(builder.name, definition)
+}
+
+func (builder *PeaDefinitionBuilder) validate(definition *SimplePeaDefinition) []string {
+	problems := make([]string, 0)
+
+	if builder.named && builder.name == "" {
+		problems = append(problems, "pea name must not be empty")
+	}
+
+	problems = append(problems, builder.validateType(definition)...)
+
+	if definition.scope != "" && definition.scope != SharedScope && definition.scope != PrototypeScope {
+		problems = append(problems, "scope '"+string(definition.scope)+"' is not supported")
+	}
+
+	if definition.IsPrimary() && !definition.IsAutowireCandidate() {
+		problems = append(problems, "primary pea must be an autowire candidate")
+	}
+
+	if definition.IsPrimary() && definition.IsAbstract() {
+		problems = append(problems, "abstract pea definition cannot be primary")
+	}
+
+	for _, dependsOn := range definition.GetDependsOn() {
+		if dependsOn == "" {
+			problems = append(problems, "depends-on pea name must not be empty")
+		} else if builder.named && dependsOn == builder.name {
+			problems = append(problems, "pea cannot depend on itself")
+		}
+	}
+
+	if definition.GetInitializationTimeout() < 0 && definition.GetInitializationTimeout() != NoInitializationTimeout {
+		problems = append(problems, "initialization timeout must not be negative")
+	}
+
+	for _, condition := range definition.GetConditions() {
+		if condition == nil {
+			problems = append(problems, "condition must not be nil")
+		}
+	}
+
+	for _, expression := range definition.GetProfiles() {
+		if _, err := parseProfileExpression(expression); err != nil {
+			problems = append(problems, err.Error())
+		}
+	}
+
+	for _, exposedType := range definition.GetExposedTypes() {
+		if exposedType == nil {
+			problems = append(problems, "exposed type must not be nil")
+		}
+	}
+
+	return problems
+}
+
+func (builder *PeaDefinitionBuilder) validateType(definition *SimplePeaDefinition) []string {
+	problems := make([]string, 0)
+
+	if builder.typErr != nil {
+		return append(problems, builder.typErr.Error())
+	}
+
+	typ := definition.GetPeaType()
+	if typ == nil {
+		if definition.GetParentName() == "" && !definition.IsAbstract() {
+			problems = append(problems, "pea type must not be nil")
+		}
+		return problems
+	}
+
+	parameterTypes := make([]goo.Type, 0)
+	instanceType := typ
+	if typ.IsFunction() {
+		fun := typ.ToFunctionType()
+		parameterTypes = fun.GetFunctionParameterTypes()
+		var ok bool
+		if instanceType, ok = getConstructorReturnType(fun); !ok {
+			return append(problems, "constructor function must have only one return type besides an error")
+		}
+	} else if !typ.IsStruct() {
+		return append(problems, "pea type must be either a struct or a constructor function")
+	}
+
+	for index, argument := range definition.GetArguments() {
+		if index < 0 || index >= len(parameterTypes) {
+			problems = append(problems, "argument index "+strconv.Itoa(index)+" is out of range")
+		} else if err := checkArgumentType(index, argument, parameterTypes[index]); err != nil {
+			problems = append(problems, err.Error())
+		}
+	}
+
+	if instanceType.IsStruct() {
+		// the methods are invoked on the instance which the constructor returns, the structs are instantiated as pointers
+		instanceGoType := getActualGoType(instanceType)
+		if !typ.IsFunction() {
+			instanceGoType = reflect.PtrTo(instanceType.GetGoType())
+		}
+		if methodName := definition.GetInitMethod(); methodName != "" {
+			if _, ok := instanceGoType.MethodByName(methodName); !ok {
+				problems = append(problems, "init method '"+methodName+"' could not be found")
+			}
+		}
+		if methodName := definition.GetDestroyMethod(); methodName != "" {
+			if _, ok := instanceGoType.MethodByName(methodName); !ok {
+				problems = append(problems, "destroy method '"+methodName+"' could not be found")
+			}
+		}
+	}
+
+	return problems
+}
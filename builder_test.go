@@ -0,0 +1,148 @@
+package peas
+
+import (
+	"github.com/procyon-projects/goo"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestPeaDefinitionBuilder_Register(t *testing.T) {
+	registry := NewDefaultPeaDefinitionRegistry()
+	peaName, err := Define(newBStruct).
+		Named("bPea").
+		Scope(PrototypeScope).
+		Primary().
+		DependsOn("aPea").
+		InitializationTimeout(time.Second).
+		Tags("test").
+		Register(registry)
+	assert.Nil(t, err)
+	assert.Equal(t, "bPea", peaName)
+
+	definition := registry.GetPeaDefinition("bPea")
+	assert.NotNil(t, definition)
+	assert.Equal(t, PrototypeScope, definition.GetScope())
+	assert.True(t, definition.IsPrimary())
+	assert.Equal(t, []string{"aPea"}, definition.GetDependsOn())
+	assert.Equal(t, time.Second, definition.GetInitializationTimeout())
+	assert.True(t, definition.HasTag("test"))
+	assert.Regexp(t, `builder_test\.go:\d+$`, definition.GetSource())
+}
+
+func TestPeaDefinitionBuilder_RegisterWithGeneratedName(t *testing.T) {
+	registry := NewDefaultPeaDefinitionRegistry()
+	peaName, err := Define(goo.GetType(newAStruct)).Register(registry)
+	assert.Nil(t, err)
+	assert.NotEmpty(t, peaName)
+	assert.True(t, registry.ContainsPeaDefinition(peaName))
+}
+
+func TestPeaDefinitionBuilder_Build(t *testing.T) {
+	definition, err := Define(func() *connectionPea {
+		return &connectionPea{}
+	}).InitMethod("Open").DestroyMethod("Shutdown").Build()
+	assert.Nil(t, err)
+	assert.Equal(t, "Open", definition.GetInitMethod())
+	assert.Equal(t, "Shutdown", definition.GetDestroyMethod())
+}
+
+func TestPeaDefinitionBuilder_BuildReportsAllProblems(t *testing.T) {
+	registry := NewDefaultPeaDefinitionRegistry()
+	_, err := Define(func() *connectionPea {
+		return &connectionPea{}
+	}).
+		Named("connection").
+		Scope("request").
+		Primary().
+		AutowireCandidate(false).
+		DependsOn("", "connection").
+		InitMethod("Close").
+		DestroyMethod("Dispose").
+		InitializationTimeout(-time.Second).
+		Argument(0, "localhost").
+		Register(registry)
+	assert.NotNil(t, err)
+	assert.False(t, registry.ContainsPeaDefinition("connection"))
+
+	validationErr, ok := err.(PeaDefinitionValidationError)
+	assert.True(t, ok)
+	assert.Equal(t, "connection", validationErr.GetPeaName())
+	assert.Equal(t, []string{
+		"argument index 0 is out of range",
+		"init method 'Close' could not be found",
+		"destroy method 'Dispose' could not be found",
+		"scope 'request' is not supported",
+		"primary pea must be an autowire candidate",
+		"depends-on pea name must not be empty",
+		"pea cannot depend on itself",
+		"initialization timeout must not be negative",
+	}, validationErr.GetProblems())
+}
+
+func TestPeaDefinitionBuilder_BuildWithInvalidType(t *testing.T) {
+	_, err := Define(nil).Named("").Build()
+	assert.Equal(t, " : Pea definition is not valid : pea name must not be empty, pea type must not be nil", err.Error())
+
+	_, err = Define(func() (aStruct, error) {
+		return aStruct{}, nil
+	}).Build()
+	assert.Nil(t, err)
+
+	_, err = Define(func() (aStruct, int) {
+		return aStruct{}, 0
+	}).Build()
+	assert.Equal(t, []string{"constructor function must have only one return type besides an error"}, err.(PeaDefinitionValidationError).GetProblems())
+
+	_, err = Define(make(chan int)).Build()
+	assert.Len(t, err.(PeaDefinitionValidationError).GetProblems(), 1)
+	assert.Contains(t, err.Error(), "pea object type is not supported")
+
+	_, err = Define("pea").Build()
+	assert.Equal(t, []string{"pea type must be either a struct or a constructor function"}, err.(PeaDefinitionValidationError).GetProblems())
+
+	definition, err := Define(nil).Parent("template").Build()
+	assert.Nil(t, err)
+	assert.Equal(t, "template", definition.GetParentName())
+}
+
+func TestPeaDefinitionBuilder_BuildWithInvalidArgumentTypes(t *testing.T) {
+	_, err := Define(newStructFunctionWithParameters).Argument(0, "test").Argument(1, 5).Argument(2, nil).Build()
+	assert.Nil(t, err)
+
+	_, err = Define(newStructFunctionWithParameters).Argument(0, 5).Argument(1, nil).Build()
+	assert.ElementsMatch(t, []string{
+		"argument 0 of type int is not assignable to parameter of type string",
+		"argument 1 cannot be nil for parameter of type int",
+	}, err.(PeaDefinitionValidationError).GetProblems())
+}
+
+func TestPeaDefinitionBuilder_BuildWithMethodsOfPointerReceiver(t *testing.T) {
+	_, err := Define(connectionPea{}).InitMethod("Open").DestroyMethod("Shutdown").Build()
+	assert.Nil(t, err)
+
+	_, err = Define(func() connectionPea {
+		return connectionPea{}
+	}).InitMethod("Open").DestroyMethod("Shutdown").Build()
+	assert.Equal(t, []string{
+		"init method 'Open' could not be found",
+		"destroy method 'Shutdown' could not be found",
+	}, err.(PeaDefinitionValidationError).GetProblems())
+}
+
+func TestPeaDefinitionBuilder_RegisterWithNilRegistry(t *testing.T) {
+	_, err := Define(newAStruct).Named("aPea").Register(nil)
+	assert.Equal(t, []string{"pea definition registry must not be nil"}, err.(PeaDefinitionValidationError).GetProblems())
+}
+
+func TestPeaDefinitionBuilder_BuildWithInvalidProfiles(t *testing.T) {
+	definition, err := Define(newAStruct).Profiles("local | test", "!prod").Build()
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"local | test", "!prod"}, definition.GetProfiles())
+
+	_, err = Define(newAStruct).Profiles("local &", "(prod").Build()
+	assert.Equal(t, []string{
+		"profile name is expected in profile expression : local &",
+		"missing ')' in profile expression : (prod",
+	}, err.(PeaDefinitionValidationError).GetProblems())
+}
@@ -0,0 +1,340 @@
+package peas
+
+import (
+	"github.com/procyon-projects/goo"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+type Condition interface {
+	Matches(ctx ConditionContext) ConditionOutcome
+}
+
+type ConditionFunc func(ctx ConditionContext) bool
+
+func (fun ConditionFunc) Matches(ctx ConditionContext) ConditionOutcome {
+	if fun(ctx) {
+		return NewConditionOutcome(true, "custom condition matched")
+	}
+	return NewConditionOutcome(false, "custom condition did not match")
+}
+
+type ConditionContext interface {
+	GetPeaName() string
+	GetPeaFactory() ListablePeaFactory
+	GetPeaDefinitionRegistry() PeaDefinitionRegistry
+	GetEnvironment() Environment
+}
+
+type conditionContext struct {
+	peaName string
+	factory DefaultPeaFactory
+}
+
+func (ctx conditionContext) GetPeaName() string {
+	return ctx.peaName
+}
+
+func (ctx conditionContext) GetPeaFactory() ListablePeaFactory {
+	return ctx.factory
+}
+
+func (ctx conditionContext) GetPeaDefinitionRegistry() PeaDefinitionRegistry {
+	return ctx.factory.PeaDefinitionRegistry
+}
+
+func (ctx conditionContext) GetEnvironment() Environment {
+	return ctx.factory.environment
+}
+
+func (ctx conditionContext) isActivePea(peaName string) bool {
+	return ctx.factory.isActivePeaDefinition(ctx.factory.getMergedPeaDefinition(ctx.factory.CanonicalName(peaName)))
+}
+
+type activePeaChecker interface {
+	isActivePea(peaName string) bool
+}
+
+// containsActivePeaDefinition ignores the definitions which are not active for the current profiles.
+func containsActivePeaDefinition(ctx ConditionContext, peaName string) bool {
+	if !ctx.GetPeaDefinitionRegistry().ContainsPeaDefinition(peaName) {
+		return false
+	}
+
+	if checker, ok := ctx.(activePeaChecker); ok {
+		return checker.isActivePea(peaName)
+	}
+	return true
+}
+
+type ConditionOutcome struct {
+	matched bool
+	message string
+}
+
+func NewConditionOutcome(matched bool, message string) ConditionOutcome {
+	return ConditionOutcome{matched, message}
+}
+
+func (outcome ConditionOutcome) IsMatch() bool {
+	return outcome.matched
+}
+
+func (outcome ConditionOutcome) GetMessage() string {
+	return outcome.message
+}
+
+type onPeaCondition struct {
+	peaName string
+	missing bool
+}
+
+// OnPea matches when a pea with the given name is present.
+func OnPea(peaName string) Condition {
+	return onPeaCondition{peaName, false}
+}
+
+// OnMissingPeaName matches when there is no pea with the given name.
+func OnMissingPeaName(peaName string) Condition {
+	return onPeaCondition{peaName, true}
+}
+
+func (condition onPeaCondition) Matches(ctx ConditionContext) ConditionOutcome {
+	if containsActivePeaDefinition(ctx, condition.peaName) || ctx.GetPeaFactory().ContainsPea(condition.peaName) {
+		return NewConditionOutcome(!condition.missing, "pea '"+condition.peaName+"' is found")
+	}
+	return NewConditionOutcome(condition.missing, "pea '"+condition.peaName+"' is not found")
+}
+
+type onPeaTypeCondition struct {
+	typ     goo.Type
+	missing bool
+}
+
+// OnPeaType matches when there is a pea of the given type other than the pea being evaluated.
+func OnPeaType(typ goo.Type) Condition {
+	return onPeaTypeCondition{typ, false}
+}
+
+// OnMissingPea matches when there is no pea of the given type other than the pea being evaluated.
+func OnMissingPea(typ goo.Type) Condition {
+	return onPeaTypeCondition{typ, true}
+}
+
+func (condition onPeaTypeCondition) Matches(ctx ConditionContext) ConditionOutcome {
+	if condition.typ == nil {
+		return NewConditionOutcome(false, "required type must not be nil")
+	}
+
+	peaNames := removeString(ctx.GetPeaFactory().GetPeaNamesForType(condition.typ, true), ctx.GetPeaName())
+	if len(peaNames) != 0 {
+		return NewConditionOutcome(!condition.missing, "peas of type '"+condition.typ.GetFullName()+"' are found : "+strings.Join(peaNames, ", "))
+	}
+	return NewConditionOutcome(condition.missing, "no pea of type '"+condition.typ.GetFullName()+"' is found")
+}
+
+type onPropertyCondition struct {
+	key   string
+	value string
+}
+
+// OnProperty matches when the property has the given value in the environment.
+func OnProperty(key string, value string) Condition {
+	return onPropertyCondition{key, value}
+}
+
+func (condition onPropertyCondition) Matches(ctx ConditionContext) ConditionOutcome {
+	environment := ctx.GetEnvironment()
+	if environment == nil {
+		return NewConditionOutcome(false, "environment is not available")
+	}
+
+	value, ok := environment.GetProperty(condition.key)
+	if !ok {
+		return NewConditionOutcome(false, "property '"+condition.key+"' is not found")
+	} else if value != condition.value {
+		return NewConditionOutcome(false, "property '"+condition.key+"' has value '"+value+"' instead of '"+condition.value+"'")
+	}
+	return NewConditionOutcome(true, "property '"+condition.key+"' has value '"+value+"'")
+}
+
+type ConditionEvaluation struct {
+	peaName  string
+	outcomes []ConditionOutcome
+}
+
+func (evaluation ConditionEvaluation) GetPeaName() string {
+	return evaluation.peaName
+}
+
+func (evaluation ConditionEvaluation) GetOutcomes() []ConditionOutcome {
+	return evaluation.outcomes
+}
+
+func (evaluation ConditionEvaluation) IsMatch() bool {
+	for _, outcome := range evaluation.outcomes {
+		if !outcome.IsMatch() {
+			return false
+		}
+	}
+	return true
+}
+
+type ConditionEvaluationReport struct {
+	evaluations []ConditionEvaluation
+	mu          sync.RWMutex
+}
+
+func newConditionEvaluationReport() *ConditionEvaluationReport {
+	return &ConditionEvaluationReport{
+		evaluations: make([]ConditionEvaluation, 0),
+		mu:          sync.RWMutex{},
+	}
+}
+
+func (report *ConditionEvaluationReport) add(evaluation ConditionEvaluation) {
+	report.mu.Lock()
+	report.evaluations = append(report.evaluations, evaluation)
+	report.mu.Unlock()
+}
+
+func (report *ConditionEvaluationReport) GetEvaluations() []ConditionEvaluation {
+	report.mu.RLock()
+	defer report.mu.RUnlock()
+	evaluations := make([]ConditionEvaluation, len(report.evaluations))
+	copy(evaluations, report.evaluations)
+	return evaluations
+}
+
+func (report *ConditionEvaluationReport) GetEvaluation(peaName string) (ConditionEvaluation, bool) {
+	report.mu.RLock()
+	defer report.mu.RUnlock()
+	for index := len(report.evaluations) - 1; index >= 0; index-- {
+		if report.evaluations[index].peaName == peaName {
+			return report.evaluations[index], true
+		}
+	}
+	return ConditionEvaluation{}, false
+}
+
+func (report *ConditionEvaluationReport) String() string {
+	var builder strings.Builder
+	for _, evaluation := range report.GetEvaluations() {
+		if evaluation.IsMatch() {
+			builder.WriteString(evaluation.peaName + " matched :\n")
+		} else {
+			builder.WriteString(evaluation.peaName + " did not match :\n")
+		}
+
+		for _, outcome := range evaluation.outcomes {
+			if outcome.IsMatch() {
+				builder.WriteString("  + " + outcome.message + "\n")
+			} else {
+				builder.WriteString("  - " + outcome.message + "\n")
+			}
+		}
+	}
+	return builder.String()
+}
+
+type conditionEvaluationState struct {
+	evaluated   int32
+	goroutineID uint64
+	mu          sync.Mutex
+}
+
+// EvaluateConditions evaluates the conditions of the pea definitions and removes the definitions whose conditions
+// do not match. The definitions having OnMissingPea or OnMissingPeaName conditions are evaluated last, so that
+// they see only the definitions which are kept. Otherwise, the definitions are evaluated in their registration order.
+// The outcomes are recorded in the condition evaluation report.
+//
+// If it is not called, the conditions are evaluated once by the first lookup or PreInstantiateSharedPeas.
+func (factory DefaultPeaFactory) EvaluateConditions() *ConditionEvaluationReport {
+	factory.evaluateConditions(false)
+	return factory.conditionReport
+}
+
+// evaluatePendingConditions evaluates the conditions unless they are already evaluated. The lookups made by
+// the conditions themselves do not wait for the evaluation in progress.
+func (factory DefaultPeaFactory) evaluatePendingConditions() {
+	state := factory.conditionState
+	if atomic.LoadInt32(&state.evaluated) == 1 || atomic.LoadUint64(&state.goroutineID) == getGoroutineID() {
+		return
+	}
+	factory.evaluateConditions(true)
+}
+
+func (factory DefaultPeaFactory) evaluateConditions(onlyIfPending bool) {
+	state := factory.conditionState
+	goroutineID := getGoroutineID()
+	if atomic.LoadUint64(&state.goroutineID) == goroutineID {
+		return
+	}
+
+	state.mu.Lock()
+	defer state.mu.Unlock()
+	if onlyIfPending && atomic.LoadInt32(&state.evaluated) == 1 {
+		return
+	}
+
+	atomic.StoreUint64(&state.goroutineID, goroutineID)
+	defer atomic.StoreUint64(&state.goroutineID, 0)
+
+	deferredPeaNames := make([]string, 0)
+	for _, peaName := range factory.GetPeaDefinitionNames() {
+		peaDefinition := factory.getMergedPeaDefinition(peaName)
+		if hasMissingPeaCondition(peaDefinition) {
+			deferredPeaNames = append(deferredPeaNames, peaName)
+			continue
+		}
+		factory.evaluatePeaDefinitionConditions(peaName, peaDefinition)
+	}
+
+	for _, peaName := range deferredPeaNames {
+		factory.evaluatePeaDefinitionConditions(peaName, factory.getMergedPeaDefinition(peaName))
+	}
+	atomic.StoreInt32(&state.evaluated, 1)
+}
+
+func (factory DefaultPeaFactory) evaluatePeaDefinitionConditions(peaName string, peaDefinition PeaDefinition) {
+	if peaDefinition == nil || peaDefinition.IsAbstract() || len(peaDefinition.GetConditions()) == 0 ||
+		!factory.isActivePeaDefinition(peaDefinition) {
+		return
+	}
+
+	ctx := conditionContext{peaName, factory}
+	evaluation := ConditionEvaluation{peaName, make([]ConditionOutcome, 0)}
+	for _, condition := range peaDefinition.GetConditions() {
+		evaluation.outcomes = append(evaluation.outcomes, condition.Matches(ctx))
+	}
+	factory.conditionReport.add(evaluation)
+
+	if !evaluation.IsMatch() {
+		factory.RemovePeaDefinition(peaName)
+	}
+}
+
+func hasMissingPeaCondition(peaDefinition PeaDefinition) bool {
+	if peaDefinition == nil {
+		return false
+	}
+
+	for _, condition := range peaDefinition.GetConditions() {
+		switch condition := condition.(type) {
+		case onPeaCondition:
+			if condition.missing {
+				return true
+			}
+		case onPeaTypeCondition:
+			if condition.missing {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (factory DefaultPeaFactory) GetConditionEvaluationReport() *ConditionEvaluationReport {
+	return factory.conditionReport
+}
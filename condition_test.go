@@ -0,0 +1,189 @@
+package peas
+
+import (
+	"github.com/procyon-projects/goo"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+type messageQueue interface {
+	Publish(message string)
+}
+
+type inMemoryQueue struct {
+}
+
+func (queue *inMemoryQueue) Publish(message string) {
+}
+
+type brokerQueue struct {
+}
+
+func (queue *brokerQueue) Publish(message string) {
+}
+
+func newInMemoryQueue() *inMemoryQueue {
+	return &inMemoryQueue{}
+}
+
+func newBrokerQueue() *brokerQueue {
+	return &brokerQueue{}
+}
+
+func TestDefaultPeaFactory_EvaluateConditionsOnMissingPea(t *testing.T) {
+	queueType := goo.GetType((*messageQueue)(nil))
+
+	peaFactory := NewDefaultPeaFactory()
+	peaFactory.RegisterPeaDefinition("brokerQueue", NewSimplePeaDefinition(goo.GetType(newBrokerQueue)))
+	peaFactory.RegisterPeaDefinition("inMemoryQueue", NewSimplePeaDefinition(goo.GetType(newInMemoryQueue),
+		WithConditionOnMissingPea(queueType)))
+
+	report := peaFactory.EvaluateConditions()
+	assert.False(t, peaFactory.ContainsPeaDefinition("inMemoryQueue"))
+
+	evaluation, ok := report.GetEvaluation("inMemoryQueue")
+	assert.True(t, ok)
+	assert.False(t, evaluation.IsMatch())
+	assert.Equal(t, []ConditionOutcome{
+		NewConditionOutcome(false, "peas of type '"+queueType.GetFullName()+"' are found : brokerQueue"),
+	}, evaluation.GetOutcomes())
+
+	pea, err := peaFactory.GetPeaByType(queueType)
+	assert.Nil(t, err)
+	assert.IsType(t, &brokerQueue{}, pea)
+
+	peaFactory = NewDefaultPeaFactory()
+	peaFactory.RegisterPeaDefinition("inMemoryQueue", NewSimplePeaDefinition(goo.GetType(newInMemoryQueue),
+		WithConditionOnMissingPea(queueType)))
+	peaFactory.EvaluateConditions()
+	assert.True(t, peaFactory.ContainsPeaDefinition("inMemoryQueue"))
+	assert.True(t, peaFactory.GetConditionEvaluationReport().GetEvaluations()[0].IsMatch())
+}
+
+func TestDefaultPeaFactory_EvaluateConditions(t *testing.T) {
+	environment := NewStandardEnvironment()
+	environment.SetProperty("messaging.type", "broker")
+
+	peaFactory := NewDefaultPeaFactory(WithEnvironment(environment))
+	peaFactory.RegisterSharedPea("aPea", aStruct{})
+	peaFactory.RegisterPeaDefinition("onPea", NewSimplePeaDefinition(goo.GetType(newBStruct), WithConditionOnPea("aPea")))
+	peaFactory.RegisterPeaDefinition("onMissingPeaName", NewSimplePeaDefinition(goo.GetType(newBStruct), WithConditionOnMissingPeaName("aPea")))
+	peaFactory.RegisterPeaDefinition("onPeaType", NewSimplePeaDefinition(goo.GetType(newBStruct), WithConditionOnPeaType(goo.GetType(aStruct{}))))
+	peaFactory.RegisterPeaDefinition("brokerQueue", NewSimplePeaDefinition(goo.GetType(newBrokerQueue),
+		WithConditionOnProperty("messaging.type", "broker")))
+	peaFactory.RegisterPeaDefinition("inMemoryQueue", NewSimplePeaDefinition(goo.GetType(newInMemoryQueue),
+		WithConditionOnProperty("messaging.type", "in-memory")))
+	peaFactory.RegisterPeaDefinition("custom", NewSimplePeaDefinition(goo.GetType(newAStruct),
+		WithConditionOnPea("brokerQueue"),
+		WithConditions(ConditionFunc(func(ctx ConditionContext) bool {
+			return ctx.GetPeaName() == "custom" && ctx.GetPeaDefinitionRegistry().ContainsPeaDefinition("brokerQueue")
+		}))))
+	peaFactory.RegisterPeaDefinition("unconditional", NewSimplePeaDefinition(goo.GetType(newAStruct)))
+
+	report := peaFactory.EvaluateConditions()
+	assert.Equal(t, []string{"onPea", "onPeaType", "brokerQueue", "custom", "unconditional"}, peaFactory.GetPeaDefinitionNames())
+	assert.Len(t, report.GetEvaluations(), 6)
+
+	_, ok := report.GetEvaluation("unconditional")
+	assert.False(t, ok)
+
+	assert.Equal(t, "onPea matched :\n"+
+		"  + pea 'aPea' is found\n"+
+		"onPeaType matched :\n"+
+		"  + peas of type '"+goo.GetType(aStruct{}).GetFullName()+"' are found : custom, unconditional, aPea\n"+
+		"brokerQueue matched :\n"+
+		"  + property 'messaging.type' has value 'broker'\n"+
+		"inMemoryQueue did not match :\n"+
+		"  - property 'messaging.type' has value 'broker' instead of 'in-memory'\n"+
+		"custom matched :\n"+
+		"  + pea 'brokerQueue' is found\n"+
+		"  + custom condition matched\n"+
+		"onMissingPeaName did not match :\n"+
+		"  - pea 'aPea' is found\n", report.String())
+}
+
+func TestDefaultPeaFactory_EvaluateConditionsOfTemplates(t *testing.T) {
+	peaFactory := NewDefaultPeaFactory()
+	peaFactory.RegisterPeaDefinition("queueTemplate", NewSimplePeaDefinition(nil,
+		WithAbstract(),
+		WithConditionOnProperty("messaging.enabled", "true")))
+	peaFactory.RegisterPeaDefinition("brokerQueue", NewSimplePeaDefinition(goo.GetType(newBrokerQueue),
+		WithParent("queueTemplate")))
+
+	report := peaFactory.EvaluateConditions()
+	assert.Equal(t, []string{"queueTemplate"}, peaFactory.GetPeaDefinitionNames())
+
+	evaluation, _ := report.GetEvaluation("brokerQueue")
+	assert.Equal(t, []ConditionOutcome{
+		NewConditionOutcome(false, "property 'messaging.enabled' is not found"),
+	}, evaluation.GetOutcomes())
+}
+
+func TestDefaultPeaFactory_EvaluateConditionsForFallbackPea(t *testing.T) {
+	queueType := goo.GetType((*messageQueue)(nil))
+
+	peaFactory := NewDefaultPeaFactory()
+	peaFactory.RegisterPeaDefinition("fallbackQueue", NewSimplePeaDefinition(goo.GetType(newInMemoryQueue),
+		WithConditionOnMissingPea(queueType)))
+	peaFactory.RegisterPeaDefinition("brokerQueue", NewSimplePeaDefinition(goo.GetType(newBrokerQueue),
+		WithConditionOnProperty("messaging.fallback-test.type", "broker")))
+
+	report := peaFactory.EvaluateConditions()
+	assert.Equal(t, []string{"fallbackQueue"}, peaFactory.GetPeaDefinitionNames())
+	assert.Equal(t, "brokerQueue", report.GetEvaluations()[0].GetPeaName())
+	assert.Equal(t, "fallbackQueue", report.GetEvaluations()[1].GetPeaName())
+
+	environment := NewStandardEnvironment()
+	environment.SetProperty("messaging.fallback-test.type", "broker")
+
+	peaFactory = NewDefaultPeaFactory(WithEnvironment(environment))
+	peaFactory.RegisterPeaDefinition("fallbackQueue", NewSimplePeaDefinition(goo.GetType(newInMemoryQueue),
+		WithConditionOnMissingPea(queueType)))
+	peaFactory.RegisterPeaDefinition("brokerQueue", NewSimplePeaDefinition(goo.GetType(newBrokerQueue),
+		WithConditionOnProperty("messaging.fallback-test.type", "broker")))
+
+	peaFactory.EvaluateConditions()
+	assert.Equal(t, []string{"brokerQueue"}, peaFactory.GetPeaDefinitionNames())
+
+	peaFactory = NewDefaultPeaFactory()
+	peaFactory.RegisterPeaDefinition("fallbackQueue", NewSimplePeaDefinition(goo.GetType(newInMemoryQueue),
+		WithConditionOnMissingPeaName("queue")))
+	peaFactory.RegisterPeaDefinition("queue", NewSimplePeaDefinition(goo.GetType(newBrokerQueue),
+		WithConditionOnPea("missingPea")))
+
+	peaFactory.EvaluateConditions()
+	assert.Equal(t, []string{"fallbackQueue"}, peaFactory.GetPeaDefinitionNames())
+}
+
+func TestDefaultPeaFactory_EvaluatePendingConditions(t *testing.T) {
+	queueType := goo.GetType((*messageQueue)(nil))
+
+	peaFactory := NewDefaultPeaFactory()
+	peaFactory.RegisterPeaDefinition("brokerQueue", NewSimplePeaDefinition(goo.GetType(newBrokerQueue)))
+	peaFactory.RegisterPeaDefinition("inMemoryQueue", NewSimplePeaDefinition(goo.GetType(newInMemoryQueue),
+		WithConditionOnMissingPea(queueType)))
+
+	pea, err := peaFactory.GetPeaByType(queueType)
+	assert.Nil(t, err)
+	assert.IsType(t, &brokerQueue{}, pea)
+	assert.False(t, peaFactory.ContainsPeaDefinition("inMemoryQueue"))
+	assert.Len(t, peaFactory.GetConditionEvaluationReport().GetEvaluations(), 1)
+
+	peaFactory = NewDefaultPeaFactory()
+	peaFactory.RegisterPeaDefinition("brokerQueue", NewSimplePeaDefinition(goo.GetType(newBrokerQueue)))
+	peaFactory.RegisterPeaDefinition("inMemoryQueue", NewSimplePeaDefinition(goo.GetType(newInMemoryQueue),
+		WithConditionOnMissingPea(queueType)))
+	peaFactory.RegisterPeaDefinition("checkedQueue", NewSimplePeaDefinition(goo.GetType(newInMemoryQueue),
+		WithConditions(ConditionFunc(func(ctx ConditionContext) bool {
+			pea, err := ctx.GetPeaFactory().GetPea("brokerQueue")
+			return err == nil && pea != nil
+		}))))
+
+	assert.Nil(t, peaFactory.PreInstantiateSharedPeas())
+	assert.Equal(t, []string{"brokerQueue", "checkedQueue"}, peaFactory.GetPeaDefinitionNames())
+	assert.Len(t, peaFactory.GetConditionEvaluationReport().GetEvaluations(), 2)
+
+	peas, err := peaFactory.GetPeasOfType(queueType, true, true)
+	assert.Nil(t, err)
+	assert.Len(t, peas, 2)
+}
@@ -0,0 +1,106 @@
+package peas
+
+import (
+	"errors"
+	"github.com/procyon-projects/goo"
+	"reflect"
+	"strings"
+)
+
+const configurationOptionsMethodSuffix = "Options"
+
+var (
+	errorType                   = reflect.TypeOf((*error)(nil)).Elem()
+	definitionOptionsType       = reflect.TypeOf([]SimplePeaDefinitionOption{})
+	frameworkCallbackInterfaces = []reflect.Type{
+		reflect.TypeOf((*PeaInitializer)(nil)).Elem(),
+		reflect.TypeOf((*ContextPeaInitializer)(nil)).Elem(),
+		reflect.TypeOf((*PeaDestroyer)(nil)).Elem(),
+		reflect.TypeOf((*PeaNameAware)(nil)).Elem(),
+		reflect.TypeOf((*PeaFactoryAware)(nil)).Elem(),
+		reflect.TypeOf((*PeaDefinitionAware)(nil)).Elem(),
+		reflect.TypeOf((*LifecyclePea)(nil)).Elem(),
+		reflect.TypeOf((*PhasedPea)(nil)).Elem(),
+		reflect.TypeOf((*Runner)(nil)).Elem(),
+	}
+)
+
+func (factory DefaultPeaFactory) RegisterConfigurationStruct(cfg interface{}) error {
+	if cfg == nil {
+		return errors.New("configuration struct must not be nil")
+	}
+
+	cfgType, err := getInstanceType(cfg)
+	if err != nil {
+		return err
+	}
+
+	if !cfgType.IsStruct() {
+		return errors.New("configuration must be an instance of struct")
+	}
+
+	if !cfgType.IsPointer() {
+		return errors.New("configuration must be a pointer to struct, the methods having pointer receivers are not found otherwise")
+	}
+
+	cfgName := toLowerCamelCase(cfgType.GetName())
+	err = factory.RegisterSharedPea(cfgName, cfg)
+	if err != nil {
+		return err
+	}
+
+	methods := cfgType.ToStructType().GetStructMethods()
+	for _, method := range methods {
+		if !isFactoryMethod(method) {
+			continue
+		}
+
+		options := []SimplePeaDefinitionOption{WithDependsOn(cfgName)}
+		for _, optionsMethod := range methods {
+			if optionsMethod.GetName() == method.GetName()+configurationOptionsMethodSuffix && isOptionsMethod(optionsMethod) {
+				options = append(options, optionsMethod.Invoke(cfg)[0].([]SimplePeaDefinitionOption)...)
+			}
+		}
+
+		constructor := reflect.ValueOf(cfg).MethodByName(method.GetName()).Interface()
+		err = factory.RegisterPeaDefinition(toLowerCamelCase(method.GetName()), NewSimplePeaDefinition(goo.GetType(constructor), options...))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func isFactoryMethod(method goo.Method) bool {
+	if !method.IsExported() || isFrameworkCallbackMethod(method.GetName()) {
+		return false
+	}
+
+	if strings.HasSuffix(method.GetName(), configurationOptionsMethodSuffix) && isOptionsMethod(method) {
+		return false
+	}
+
+	returnTypes := method.GetMethodReturnTypes()
+	if len(returnTypes) == 2 && getActualGoType(returnTypes[1]) != errorType || len(returnTypes) == 0 || len(returnTypes) > 2 {
+		return false
+	}
+
+	peaType := returnTypes[0]
+	return (peaType.IsPointer() || peaType.IsStruct() || peaType.IsInterface()) && getActualGoType(peaType) != errorType
+}
+
+func isOptionsMethod(method goo.Method) bool {
+	returnTypes := method.GetMethodReturnTypes()
+	return method.GetMethodParameterCount() == 1 &&
+		len(returnTypes) == 1 &&
+		getActualGoType(returnTypes[0]) == definitionOptionsType
+}
+
+func isFrameworkCallbackMethod(methodName string) bool {
+	for _, callbackInterface := range frameworkCallbackInterfaces {
+		if _, ok := callbackInterface.MethodByName(methodName); ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,93 @@
+package peas
+
+import (
+	"errors"
+	"github.com/procyon-projects/goo"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+type dbSettings struct {
+	url string
+}
+
+type dbPool struct {
+	settings dbSettings
+}
+
+type dbConnection struct {
+}
+
+type dbConfig struct {
+	url string
+}
+
+func (cfg *dbConfig) Settings() dbSettings {
+	return dbSettings{cfg.url}
+}
+
+func (cfg *dbConfig) Pool(settings dbSettings) *dbPool {
+	return &dbPool{settings}
+}
+
+func (cfg *dbConfig) PoolOptions() []SimplePeaDefinitionOption {
+	return []SimplePeaDefinitionOption{WithScope(PrototypeScope)}
+}
+
+func (cfg *dbConfig) Validate() error {
+	return errors.New("invalid configuration")
+}
+
+func (cfg *dbConfig) Connection() (*dbConnection, error) {
+	return nil, errors.New("connection refused")
+}
+
+func (cfg *dbConfig) String() string {
+	return cfg.url
+}
+
+func (cfg *dbConfig) InitializePea() error {
+	return nil
+}
+
+func (cfg *dbConfig) helper() dbSettings {
+	return dbSettings{}
+}
+
+func TestDefaultPeaFactory_RegisterConfigurationStruct(t *testing.T) {
+	peaFactory := NewDefaultPeaFactory()
+	err := peaFactory.RegisterConfigurationStruct(&dbConfig{"postgres://localhost"})
+	assert.Nil(t, err)
+
+	assert.True(t, peaFactory.ContainsSharedPea("dbConfig"))
+	assert.Equal(t, []string{"connection", "pool", "settings"}, peaFactory.GetPeaDefinitionNames())
+	assert.Equal(t, []string{"dbConfig"}, peaFactory.GetPeaDefinition("pool").GetDependsOn())
+	assert.Equal(t, PrototypeScope, peaFactory.GetPeaDefinition("pool").GetScope())
+	assert.Equal(t, SharedScope, peaFactory.GetPeaDefinition("settings").GetScope())
+
+	pea, err := peaFactory.GetPeaByType(goo.GetType(&dbPool{}))
+	assert.Nil(t, err)
+	assert.Equal(t, "postgres://localhost", pea.(*dbPool).settings.url)
+	assert.True(t, peaFactory.ContainsSharedPea("settings"))
+
+	_, err = peaFactory.GetPea("connection")
+	assert.NotNil(t, err)
+	assert.Equal(t, "connection refused", creationErrorCause(t, err).Error())
+}
+
+func TestDefaultPeaFactory_RegisterConfigurationStructWithInvalidConfiguration(t *testing.T) {
+	peaFactory := NewDefaultPeaFactory()
+	err := peaFactory.RegisterConfigurationStruct(nil)
+	assert.NotNil(t, err)
+	assert.Equal(t, "configuration struct must not be nil", err.Error())
+
+	err = peaFactory.RegisterConfigurationStruct("configuration")
+	assert.NotNil(t, err)
+	assert.Equal(t, "configuration must be an instance of struct", err.Error())
+
+	err = peaFactory.RegisterConfigurationStruct(dbConfig{"postgres://localhost"})
+	assert.NotNil(t, err)
+	assert.Equal(t, "configuration must be a pointer to struct, the methods having pointer receivers are not found otherwise", err.Error())
+	assert.Equal(t, 0, peaFactory.GetPeaDefinitionCount())
+	assert.False(t, peaFactory.ContainsSharedPea("dbConfig"))
+}
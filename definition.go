@@ -1,35 +1,77 @@
 package peas
 
 import (
+	"errors"
 	"github.com/procyon-projects/goo"
+	"reflect"
 	"sync"
+	"time"
 )
 
 type PeaDefinition interface {
 	GetTypeName() string
 	GetPeaType() goo.Type
 	GetScope() PeaScope
+	GetInitializationTimeout() time.Duration
+	GetExposedTypes() []goo.Type
+	IsLazyInit() bool
+	IsAutowireCandidate() bool
+	IsPrimary() bool
+	GetDependsOn() []string
+	GetInitMethod() string
+	GetDestroyMethod() string
+	GetArguments() map[int]interface{}
+	IsAbstract() bool
+	GetParentName() string
+	GetAttributes() map[string]interface{}
+	GetAttribute(key string) interface{}
+	GetTags() []string
+	HasTag(tag string) bool
+	GetDescription() string
+	GetRole() PeaRole
+	GetSource() string
+	GetConditions() []Condition
+	GetProfiles() []string
 }
 
 type SimplePeaDefinitionOption func(definition *SimplePeaDefinition)
 
 type SimplePeaDefinition struct {
-	typ   goo.Type
-	scope PeaScope
+	typ                   goo.Type
+	scope                 PeaScope
+	initializationTimeout time.Duration
+	exposedTypes          []goo.Type
+	lazyInit              *bool
+	autowireCandidate     *bool
+	primary               bool
+	dependsOn             []string
+	initMethod            string
+	destroyMethod         string
+	arguments             map[int]interface{}
+	abstract              bool
+	parentName            string
+	attributes            map[string]interface{}
+	tags                  []string
+	description           string
+	role                  PeaRole
+	source                string
+	conditions            []Condition
+	profiles              []string
 }
 
 func NewSimplePeaDefinition(typ goo.Type, options ...SimplePeaDefinitionOption) *SimplePeaDefinition {
 	def := &SimplePeaDefinition{
-		typ: typ,
+		typ:        typ,
+		arguments:  make(map[int]interface{}, 0),
+		attributes: make(map[string]interface{}, 0),
+		tags:       make([]string, 0),
+		source:     getCallerLocation(),
 	}
 
 	for _, option := range options {
 		option(def)
 	}
 
-	if def.scope == "" {
-		def.scope = SharedScope
-	}
 	return def
 }
 
@@ -39,9 +81,8 @@ func (def *SimplePeaDefinition) GetTypeName() string {
 	}
 
 	if def.typ.IsFunction() {
-		fun := def.typ.ToFunctionType()
-		if fun.GetFunctionReturnTypeCount() == 1 {
-			return fun.GetFunctionReturnTypes()[0].GetFullName()
+		if returnType, ok := getConstructorReturnType(def.typ.ToFunctionType()); ok {
+			return returnType.GetFullName()
 		}
 	}
 
@@ -53,53 +94,433 @@ func (def *SimplePeaDefinition) GetPeaType() goo.Type {
 }
 
 func (def *SimplePeaDefinition) GetScope() PeaScope {
+	if def.scope == "" {
+		return SharedScope
+	}
 	return def.scope
 }
 
+func (def *SimplePeaDefinition) GetInitializationTimeout() time.Duration {
+	return def.initializationTimeout
+}
+
+func (def *SimplePeaDefinition) GetExposedTypes() []goo.Type {
+	return def.exposedTypes
+}
+
+func (def *SimplePeaDefinition) IsLazyInit() bool {
+	return def.lazyInit != nil && *def.lazyInit
+}
+
+func (def *SimplePeaDefinition) IsAutowireCandidate() bool {
+	return def.autowireCandidate == nil || *def.autowireCandidate
+}
+
+func (def *SimplePeaDefinition) IsPrimary() bool {
+	return def.primary
+}
+
+func (def *SimplePeaDefinition) GetDependsOn() []string {
+	return def.dependsOn
+}
+
+func (def *SimplePeaDefinition) GetInitMethod() string {
+	return def.initMethod
+}
+
+func (def *SimplePeaDefinition) GetDestroyMethod() string {
+	return def.destroyMethod
+}
+
+func (def *SimplePeaDefinition) GetArguments() map[int]interface{} {
+	return def.arguments
+}
+
+func (def *SimplePeaDefinition) IsAbstract() bool {
+	return def.abstract
+}
+
+func (def *SimplePeaDefinition) GetParentName() string {
+	return def.parentName
+}
+
+func (def *SimplePeaDefinition) GetAttributes() map[string]interface{} {
+	return def.attributes
+}
+
+func (def *SimplePeaDefinition) GetAttribute(key string) interface{} {
+	return def.attributes[key]
+}
+
+func (def *SimplePeaDefinition) GetTags() []string {
+	return def.tags
+}
+
+func (def *SimplePeaDefinition) HasTag(tag string) bool {
+	return containsString(def.tags, tag)
+}
+
+func (def *SimplePeaDefinition) GetDescription() string {
+	return def.description
+}
+
+func (def *SimplePeaDefinition) GetRole() PeaRole {
+	if def.role == "" {
+		return ApplicationRole
+	}
+	return def.role
+}
+
+func (def *SimplePeaDefinition) GetSource() string {
+	return def.source
+}
+
+func (def *SimplePeaDefinition) GetConditions() []Condition {
+	return def.conditions
+}
+
+func (def *SimplePeaDefinition) GetProfiles() []string {
+	return def.profiles
+}
+
 func WithScope(scope PeaScope) SimplePeaDefinitionOption {
 	return func(definition *SimplePeaDefinition) {
 		definition.scope = scope
 	}
 }
 
+// NoInitializationTimeout disables the initialization timeout of a definition even if the factory has a default one.
+const NoInitializationTimeout time.Duration = -1
+
+func WithInitializationTimeout(timeout time.Duration) SimplePeaDefinitionOption {
+	return func(definition *SimplePeaDefinition) {
+		definition.initializationTimeout = timeout
+	}
+}
+
+func WithExposedTypes(types ...goo.Type) SimplePeaDefinitionOption {
+	return func(definition *SimplePeaDefinition) {
+		definition.exposedTypes = append(definition.exposedTypes, types...)
+	}
+}
+
+func WithLazyInit() SimplePeaDefinitionOption {
+	return func(definition *SimplePeaDefinition) {
+		lazyInit := true
+		definition.lazyInit = &lazyInit
+	}
+}
+
+func WithAutowireCandidate(candidate bool) SimplePeaDefinitionOption {
+	return func(definition *SimplePeaDefinition) {
+		definition.autowireCandidate = &candidate
+	}
+}
+
+func WithPrimary() SimplePeaDefinitionOption {
+	return func(definition *SimplePeaDefinition) {
+		definition.primary = true
+	}
+}
+
+func WithDependsOn(peaNames ...string) SimplePeaDefinitionOption {
+	return func(definition *SimplePeaDefinition) {
+		definition.dependsOn = append(definition.dependsOn, peaNames...)
+	}
+}
+
+func WithInitMethod(methodName string) SimplePeaDefinitionOption {
+	return func(definition *SimplePeaDefinition) {
+		definition.initMethod = methodName
+	}
+}
+
+func WithDestroyMethod(methodName string) SimplePeaDefinitionOption {
+	return func(definition *SimplePeaDefinition) {
+		definition.destroyMethod = methodName
+	}
+}
+
+func WithArgument(index int, value interface{}) SimplePeaDefinitionOption {
+	return func(definition *SimplePeaDefinition) {
+		definition.arguments[index] = value
+	}
+}
+
+func WithAbstract() SimplePeaDefinitionOption {
+	return func(definition *SimplePeaDefinition) {
+		definition.abstract = true
+	}
+}
+
+func WithParent(parentName string) SimplePeaDefinitionOption {
+	return func(definition *SimplePeaDefinition) {
+		definition.parentName = parentName
+	}
+}
+
+func WithAttribute(key string, value interface{}) SimplePeaDefinitionOption {
+	return func(definition *SimplePeaDefinition) {
+		definition.attributes[key] = value
+	}
+}
+
+func WithTags(tags ...string) SimplePeaDefinitionOption {
+	return func(definition *SimplePeaDefinition) {
+		for _, tag := range tags {
+			if !containsString(definition.tags, tag) {
+				definition.tags = append(definition.tags, tag)
+			}
+		}
+	}
+}
+
+func WithDescription(description string) SimplePeaDefinitionOption {
+	return func(definition *SimplePeaDefinition) {
+		definition.description = description
+	}
+}
+
+func WithRole(role PeaRole) SimplePeaDefinitionOption {
+	return func(definition *SimplePeaDefinition) {
+		definition.role = role
+	}
+}
+
+func WithSource(source string) SimplePeaDefinitionOption {
+	return func(definition *SimplePeaDefinition) {
+		definition.source = source
+	}
+}
+
+func WithConditions(conditions ...Condition) SimplePeaDefinitionOption {
+	return func(definition *SimplePeaDefinition) {
+		definition.conditions = append(definition.conditions, conditions...)
+	}
+}
+
+func WithConditionOnPea(peaName string) SimplePeaDefinitionOption {
+	return WithConditions(OnPea(peaName))
+}
+
+func WithConditionOnMissingPeaName(peaName string) SimplePeaDefinitionOption {
+	return WithConditions(OnMissingPeaName(peaName))
+}
+
+func WithConditionOnPeaType(typ goo.Type) SimplePeaDefinitionOption {
+	return WithConditions(OnPeaType(typ))
+}
+
+func WithConditionOnMissingPea(typ goo.Type) SimplePeaDefinitionOption {
+	return WithConditions(OnMissingPea(typ))
+}
+
+func WithConditionOnProperty(key string, value string) SimplePeaDefinitionOption {
+	return WithConditions(OnProperty(key, value))
+}
+
+// WithProfiles makes the definition active only when one of the given profile expressions matches
+// the active profiles. An expression like "local & !prod" can combine the profiles with '!', '&', '|' and parentheses.
+func WithProfiles(expressions ...string) SimplePeaDefinitionOption {
+	return func(definition *SimplePeaDefinition) {
+		definition.profiles = append(definition.profiles, expressions...)
+	}
+}
+
+type DefinitionOverridingPolicy string
+
+const (
+	AllowDefinitionOverriding DefinitionOverridingPolicy = "allow"
+	DenyDefinitionOverriding  DefinitionOverridingPolicy = "deny"
+	WarnDefinitionOverriding  DefinitionOverridingPolicy = "warn"
+)
+
 type PeaDefinitionRegistry interface {
-	RegisterPeaDefinition(peaName string, definition PeaDefinition)
+	AliasRegistry
+	RegisterPeaDefinition(peaName string, definition PeaDefinition) error
+	RegisterPeaDefinitionWithGeneratedName(definition PeaDefinition) (string, error)
 	RemovePeaDefinition(peaName string)
 	ContainsPeaDefinition(peaName string) bool
 	GetPeaDefinition(peaName string) PeaDefinition
 	GetPeaDefinitionNames() []string
 	GetPeaDefinitionCount() int
 	GetPeaNamesByType(typ goo.Type) []string
+	GetPeaNamesByTag(tag string) []string
+	GetPeaNamesByAttribute(key string, value interface{}) []string
 }
 
 type DefaultPeaDefinitionRegistry struct {
-	definitions map[string]PeaDefinition
-	mu          sync.RWMutex
+	*DefaultAliasRegistry
+	definitions           map[string]PeaDefinition
+	definitionNames       []string
+	registrationLocations map[string]string
+	overridingPolicy      DefinitionOverridingPolicy
+	logger                Logger
+	nameGenerator         PeaNameGenerator
+	typeMatcher           TypeMatcher
+	typeIndex             map[reflect.Type][]string
+	mu                    sync.RWMutex
+	muNameGeneration      sync.Mutex
+	muTypeIndex           sync.Mutex
 }
 
 func NewDefaultPeaDefinitionRegistry() *DefaultPeaDefinitionRegistry {
 	return &DefaultPeaDefinitionRegistry{
-		definitions: make(map[string]PeaDefinition, 0),
-		mu:          sync.RWMutex{},
+		DefaultAliasRegistry:  NewDefaultAliasRegistry(),
+		definitions:           make(map[string]PeaDefinition, 0),
+		definitionNames:       make([]string, 0),
+		registrationLocations: make(map[string]string, 0),
+		overridingPolicy:      WarnDefinitionOverriding,
+		logger:                NewDefaultLogger(),
+		nameGenerator:         NewDefaultPeaNameGenerator(),
+		typeMatcher:           NewDefaultTypeMatcher(),
+		typeIndex:             make(map[reflect.Type][]string, 0),
+		mu:                    sync.RWMutex{},
+		muNameGeneration:      sync.Mutex{},
+		muTypeIndex:           sync.Mutex{},
 	}
 }
 
-func (registry *DefaultPeaDefinitionRegistry) RegisterPeaDefinition(peaName string, definition PeaDefinition) {
+func (registry *DefaultPeaDefinitionRegistry) SetDefinitionOverridingPolicy(policy DefinitionOverridingPolicy) {
+	registry.mu.Lock()
+	registry.overridingPolicy = policy
+	registry.mu.Unlock()
+}
+
+func (registry *DefaultPeaDefinitionRegistry) GetDefinitionOverridingPolicy() DefinitionOverridingPolicy {
+	registry.mu.RLock()
+	defer registry.mu.RUnlock()
+	return registry.overridingPolicy
+}
+
+func (registry *DefaultPeaDefinitionRegistry) SetTypeMatcher(matcher TypeMatcher) {
+	registry.mu.Lock()
+	registry.typeMatcher = matcher
+	registry.invalidateTypeIndex()
+	registry.mu.Unlock()
+}
+
+func (registry *DefaultPeaDefinitionRegistry) GetTypeMatcher() TypeMatcher {
+	registry.mu.RLock()
+	defer registry.mu.RUnlock()
+	return registry.typeMatcher
+}
+
+func (registry *DefaultPeaDefinitionRegistry) SetLogger(logger Logger) {
 	registry.mu.Lock()
+	registry.logger = logger
+	registry.mu.Unlock()
+	registry.DefaultAliasRegistry.SetLogger(logger)
+}
+
+func (registry *DefaultPeaDefinitionRegistry) SetPeaNameGenerator(generator PeaNameGenerator) {
+	registry.mu.Lock()
+	registry.nameGenerator = generator
+	registry.mu.Unlock()
+}
+
+func (registry *DefaultPeaDefinitionRegistry) GetPeaNameGenerator() PeaNameGenerator {
+	registry.mu.RLock()
+	defer registry.mu.RUnlock()
+	return registry.nameGenerator
+}
+
+func (registry *DefaultPeaDefinitionRegistry) RegisterPeaDefinitionWithGeneratedName(definition PeaDefinition) (string, error) {
+	if definition == nil {
+		return "", errors.New("pea definition must not be nil")
+	}
+
+	registry.muNameGeneration.Lock()
+	defer registry.muNameGeneration.Unlock()
+
+	generator := registry.GetPeaNameGenerator()
+	var peaName string
+	if registryAwareGenerator, ok := generator.(RegistryAwarePeaNameGenerator); ok {
+		peaName = registryAwareGenerator.GenerateUniqueName(definition, registry)
+	} else {
+		peaName = generator.GenerateName(definition)
+	}
+	if peaName == "" {
+		return "", errors.New("pea name could not be generated for pea definition : " + definition.GetTypeName())
+	}
+	return peaName, registry.RegisterPeaDefinition(peaName, definition)
+}
+
+func (registry *DefaultPeaDefinitionRegistry) RegisterAlias(name string, alias string) error {
+	if registry.ContainsPeaDefinition(alias) && !registry.IsAlias(alias) {
+		return errors.New("alias '" + alias + "' cannot be registered, there is already a pea definition with the same name")
+	}
+	return registry.DefaultAliasRegistry.RegisterAlias(name, alias)
+}
+
+func (registry *DefaultPeaDefinitionRegistry) RegisterPeaDefinition(peaName string, definition PeaDefinition) error {
+	if peaName == "" {
+		return errors.New("pea name must not be empty")
+	}
+
+	if definition == nil {
+		return errors.New("pea definition must not be nil")
+	}
+
+	if registry.IsAlias(peaName) {
+		return errors.New("pea definition '" + peaName + "' cannot be registered, there is already an alias with the same name")
+	}
+
+	for _, expression := range definition.GetProfiles() {
+		if _, err := parseProfileExpression(expression); err != nil {
+			return errors.New("pea definition '" + peaName + "' could not be registered : " + err.Error())
+		}
+	}
+
+	location := getCallerLocation()
+
+	registry.mu.Lock()
+	var overrideErr PeaDefinitionOverrideError
+	existingDefinition, exists := registry.definitions[peaName]
+	if exists {
+		overrideErr = NewPeaDefinitionOverrideError(peaName,
+			existingDefinition,
+			registry.registrationLocations[peaName],
+			definition,
+			location,
+		)
+
+		if registry.overridingPolicy == DenyDefinitionOverriding {
+			registry.mu.Unlock()
+			return overrideErr
+		}
+	} else {
+		registry.definitionNames = append(registry.definitionNames, peaName)
+	}
+
 	registry.definitions[peaName] = definition
+	registry.registrationLocations[peaName] = location
+	registry.invalidateTypeIndex()
+	policy := registry.overridingPolicy
+	logger := registry.logger
 	registry.mu.Unlock()
+
+	if exists && policy == WarnDefinitionOverriding && logger != nil {
+		logger.Warn(overrideErr.Error())
+	}
+	return nil
 }
 
 func (registry *DefaultPeaDefinitionRegistry) RemovePeaDefinition(peaName string) {
+	peaName = registry.resolveCanonicalName(peaName)
 	registry.mu.Lock()
 	if _, ok := registry.definitions[peaName]; ok {
 		delete(registry.definitions, peaName)
+		delete(registry.registrationLocations, peaName)
+		registry.definitionNames = removeString(registry.definitionNames, peaName)
+		registry.invalidateTypeIndex()
 	}
 	registry.mu.Unlock()
 }
 
 func (registry *DefaultPeaDefinitionRegistry) ContainsPeaDefinition(peaName string) bool {
 	var result bool
+	peaName = registry.resolveCanonicalName(peaName)
 	registry.mu.Lock()
 	_, result = registry.definitions[peaName]
 	registry.mu.Unlock()
@@ -108,6 +529,7 @@ func (registry *DefaultPeaDefinitionRegistry) ContainsPeaDefinition(peaName stri
 
 func (registry *DefaultPeaDefinitionRegistry) GetPeaDefinition(peaName string) PeaDefinition {
 	var def PeaDefinition
+	peaName = registry.resolveCanonicalName(peaName)
 	registry.mu.Lock()
 	if val, ok := registry.definitions[peaName]; ok {
 		def = val
@@ -116,44 +538,107 @@ func (registry *DefaultPeaDefinitionRegistry) GetPeaDefinition(peaName string) P
 	return def
 }
 
+func (registry *DefaultPeaDefinitionRegistry) getPeaDefinition(peaName string) PeaDefinition {
+	return registry.definitions[registry.resolveCanonicalName(peaName)]
+}
+
+func (registry *DefaultPeaDefinitionRegistry) resolveCanonicalName(peaName string) string {
+	registry.DefaultAliasRegistry.mu.RLock()
+	defer registry.DefaultAliasRegistry.mu.RUnlock()
+	return registry.resolveAlias(peaName)
+}
+
 func (registry *DefaultPeaDefinitionRegistry) GetPeaDefinitionNames() []string {
-	return getStringMapKeys(registry.definitions)
+	registry.mu.RLock()
+	defer registry.mu.RUnlock()
+	return append(make([]string, 0, len(registry.definitionNames)), registry.definitionNames...)
 }
 
 func (registry *DefaultPeaDefinitionRegistry) GetPeaDefinitionCount() int {
+	registry.mu.RLock()
+	defer registry.mu.RUnlock()
 	return len(registry.definitions)
 }
 
 func (registry *DefaultPeaDefinitionRegistry) GetPeaNamesByType(typ goo.Type) []string {
+	if typ == nil {
+		return make([]string, 0)
+	}
+
+	registry.mu.RLock()
+	defer registry.mu.RUnlock()
+
+	key := getActualGoType(typ)
+	registry.muTypeIndex.Lock()
+	peaNames, ok := registry.typeIndex[key]
+	registry.muTypeIndex.Unlock()
+
+	if !ok {
+		peaNames = registry.findPeaNamesByType(typ)
+		registry.muTypeIndex.Lock()
+		registry.typeIndex[key] = peaNames
+		registry.muTypeIndex.Unlock()
+	}
+
+	return append(make([]string, 0, len(peaNames)), peaNames...)
+}
+
+func (registry *DefaultPeaDefinitionRegistry) findPeaNamesByType(typ goo.Type) []string {
 	result := make([]string, 0)
-	for peaName, peaDefinition := range registry.definitions {
-		peaType := peaDefinition.GetPeaType()
-
-		if peaType.IsFunction() {
-			fun := peaType.ToFunctionType()
-			if fun.GetFunctionReturnTypeCount() == 1 {
-				peaType = fun.GetFunctionReturnTypes()[0]
-			} else {
-				continue
-			}
-		}
-		match := false
-
-		if typ.IsInterface() && peaType.IsStruct() && peaType.ToStructType().Implements(typ.ToInterfaceType()) {
-			match = true
-		} else if typ.IsStruct() && peaType.IsStruct() {
-			if typ.GetGoType() == peaType.GetGoType() && peaType.IsPointer() && !typ.IsPointer() {
-				match = true
-			} else if peaType.ToStructType().EmbeddedStruct(typ.ToStructType()) {
-				match = true
-			} else if typ.GetGoType() == peaType.GetGoType() {
-				match = true
-			}
+	for _, peaName := range registry.definitionNames {
+		peaDefinition := registry.getConcretePeaDefinition(peaName)
+		if peaDefinition != nil && matchesDefinition(registry.typeMatcher, peaDefinition, typ) {
+			result = append(result, peaName)
 		}
+	}
+	return result
+}
+
+func (registry *DefaultPeaDefinitionRegistry) GetPeaNamesByTag(tag string) []string {
+	return registry.getPeaNamesBy(func(definition PeaDefinition) bool {
+		return definition.HasTag(tag)
+	})
+}
+
+func (registry *DefaultPeaDefinitionRegistry) GetPeaNamesByAttribute(key string, value interface{}) []string {
+	return registry.getPeaNamesBy(func(definition PeaDefinition) bool {
+		attribute, ok := definition.GetAttributes()[key]
+		return ok && reflect.DeepEqual(attribute, value)
+	})
+}
+
+func (registry *DefaultPeaDefinitionRegistry) getPeaNamesBy(predicate func(definition PeaDefinition) bool) []string {
+	registry.mu.RLock()
+	defer registry.mu.RUnlock()
 
-		if match {
+	result := make([]string, 0)
+	for _, peaName := range registry.definitionNames {
+		peaDefinition := registry.getConcretePeaDefinition(peaName)
+		if peaDefinition != nil && predicate(peaDefinition) {
 			result = append(result, peaName)
 		}
 	}
 	return result
 }
+
+func (registry *DefaultPeaDefinitionRegistry) getConcretePeaDefinition(peaName string) PeaDefinition {
+	peaDefinition := registry.definitions[peaName]
+	if peaDefinition == nil || peaDefinition.IsAbstract() {
+		return nil
+	}
+
+	if peaDefinition.GetParentName() != "" {
+		chain, err := getPeaDefinitionChain(peaName, registry.getPeaDefinition)
+		if err != nil {
+			return nil
+		}
+		peaDefinition = mergePeaDefinitionChain(chain)
+	}
+	return peaDefinition
+}
+
+func (registry *DefaultPeaDefinitionRegistry) invalidateTypeIndex() {
+	registry.muTypeIndex.Lock()
+	registry.typeIndex = make(map[reflect.Type][]string, 0)
+	registry.muTypeIndex.Unlock()
+}
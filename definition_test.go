@@ -3,6 +3,7 @@ package peas
 import (
 	"github.com/procyon-projects/goo"
 	"github.com/stretchr/testify/assert"
+	"strconv"
 	"testing"
 )
 
@@ -96,6 +97,21 @@ func TestDefaultPeaDefinitionRegistry_GetPeaDefinitionNames(t *testing.T) {
 	assert.Contains(t, peaDefinitionNames, "testPea2")
 }
 
+func TestDefaultPeaDefinitionRegistry_GetPeaDefinitionNamesInRegistrationOrder(t *testing.T) {
+	peaDefinitionRegistry := NewDefaultPeaDefinitionRegistry()
+
+	peaNames := []string{"testPea5", "testPea1", "testPea4", "testPea2", "testPea3"}
+	for _, peaName := range peaNames {
+		peaDefinitionRegistry.RegisterPeaDefinition(peaName, NewSimplePeaDefinition(goo.GetType(testStruct{})))
+	}
+	assert.Equal(t, peaNames, peaDefinitionRegistry.GetPeaDefinitionNames())
+	assert.Equal(t, peaNames, peaDefinitionRegistry.GetPeaNamesByType(goo.GetType((*testInterface)(nil))))
+
+	peaDefinitionRegistry.RegisterPeaDefinition("testPea1", NewSimplePeaDefinition(goo.GetType(testStruct{})))
+	peaDefinitionRegistry.RemovePeaDefinition("testPea4")
+	assert.Equal(t, []string{"testPea5", "testPea1", "testPea2", "testPea3"}, peaDefinitionRegistry.GetPeaDefinitionNames())
+}
+
 func TestDefaultPeaDefinitionRegistry_GetPeaNamesForType(t *testing.T) {
 	peaDefinitionRegistry := NewDefaultPeaDefinitionRegistry()
 
@@ -146,3 +162,201 @@ func TestDefaultPeaDefinitionRegistry_RemovePeaDefinition(t *testing.T) {
 	peaDefinitionRegistry.RemovePeaDefinition("testPea2")
 	assert.Equal(t, 0, peaDefinitionRegistry.GetPeaDefinitionCount())
 }
+
+type testLogger struct {
+	warnings []string
+}
+
+func (logger *testLogger) Warn(message string) {
+	logger.warnings = append(logger.warnings, message)
+}
+
+func TestDefaultPeaDefinitionRegistry_RegisterPeaDefinitionWithEmptyNameOrNilDefinition(t *testing.T) {
+	peaDefinitionRegistry := NewDefaultPeaDefinitionRegistry()
+
+	err := peaDefinitionRegistry.RegisterPeaDefinition("", NewSimplePeaDefinition(goo.GetType(testStruct{})))
+	assert.NotNil(t, err)
+	assert.Equal(t, "pea name must not be empty", err.Error())
+
+	err = peaDefinitionRegistry.RegisterPeaDefinition("testPea", nil)
+	assert.NotNil(t, err)
+	assert.Equal(t, "pea definition must not be nil", err.Error())
+	assert.Equal(t, 0, peaDefinitionRegistry.GetPeaDefinitionCount())
+}
+
+func TestDefaultPeaDefinitionRegistry_RegisterPeaDefinitionWhenOverridingIsDenied(t *testing.T) {
+	peaDefinitionRegistry := NewDefaultPeaDefinitionRegistry()
+	peaDefinitionRegistry.SetDefinitionOverridingPolicy(DenyDefinitionOverriding)
+	assert.Equal(t, DenyDefinitionOverriding, peaDefinitionRegistry.GetDefinitionOverridingPolicy())
+
+	peaDefinition1 := NewSimplePeaDefinition(goo.GetType(testStruct{}))
+	err := peaDefinitionRegistry.RegisterPeaDefinition("testPea", peaDefinition1)
+	assert.Nil(t, err)
+
+	peaDefinition2 := NewSimplePeaDefinition(goo.GetType(testStruct2{}))
+	err = peaDefinitionRegistry.RegisterPeaDefinition("testPea", peaDefinition2)
+	assert.NotNil(t, err)
+
+	overrideErr, ok := err.(PeaDefinitionOverrideError)
+	assert.True(t, ok)
+	assert.Equal(t, "testPea", overrideErr.GetPeaName())
+	assert.Equal(t, peaDefinition1, overrideErr.GetExistingDefinition())
+	assert.Equal(t, peaDefinition2, overrideErr.GetNewDefinition())
+	assert.Regexp(t, `definition_test\.go:\d+$`, overrideErr.GetExistingLocation())
+	assert.Regexp(t, `definition_test\.go:\d+$`, overrideErr.GetNewLocation())
+	assert.NotEqual(t, overrideErr.GetExistingLocation(), overrideErr.GetNewLocation())
+	assert.Equal(t, peaDefinition1, peaDefinitionRegistry.GetPeaDefinition("testPea"))
+}
+
+func TestDefaultPeaDefinitionRegistry_RegisterPeaDefinitionWhenOverridingIsAllowed(t *testing.T) {
+	logger := &testLogger{}
+	peaDefinitionRegistry := NewDefaultPeaDefinitionRegistry()
+	peaDefinitionRegistry.SetDefinitionOverridingPolicy(AllowDefinitionOverriding)
+	peaDefinitionRegistry.SetLogger(logger)
+
+	err := peaDefinitionRegistry.RegisterPeaDefinition("testPea", NewSimplePeaDefinition(goo.GetType(testStruct{})))
+	assert.Nil(t, err)
+
+	peaDefinition := NewSimplePeaDefinition(goo.GetType(testStruct2{}))
+	err = peaDefinitionRegistry.RegisterPeaDefinition("testPea", peaDefinition)
+	assert.Nil(t, err)
+	assert.Equal(t, peaDefinition, peaDefinitionRegistry.GetPeaDefinition("testPea"))
+	assert.Empty(t, logger.warnings)
+}
+
+func TestDefaultPeaDefinitionRegistry_RegisterPeaDefinitionWhenOverridingIsWarned(t *testing.T) {
+	logger := &testLogger{}
+	peaDefinitionRegistry := NewDefaultPeaDefinitionRegistry()
+	peaDefinitionRegistry.SetLogger(logger)
+	assert.Equal(t, WarnDefinitionOverriding, peaDefinitionRegistry.GetDefinitionOverridingPolicy())
+
+	err := peaDefinitionRegistry.RegisterPeaDefinition("testPea", NewSimplePeaDefinition(goo.GetType(testStruct{})))
+	assert.Nil(t, err)
+	assert.Empty(t, logger.warnings)
+
+	peaDefinition := NewSimplePeaDefinition(goo.GetType(testStruct2{}))
+	err = peaDefinitionRegistry.RegisterPeaDefinition("testPea", peaDefinition)
+	assert.Nil(t, err)
+	assert.Equal(t, peaDefinition, peaDefinitionRegistry.GetPeaDefinition("testPea"))
+	assert.Equal(t, 1, len(logger.warnings))
+	assert.Regexp(t, `^testPea : Pea definition 'testStruct' registered at .*definition_test\.go:\d+ is overridden by pea definition 'testStruct2' registered at .*definition_test\.go:\d+$`, logger.warnings[0])
+}
+
+func TestDefaultPeaDefinitionRegistry_GetPeaNamesByTypeInvalidatesTypeIndex(t *testing.T) {
+	registry := NewDefaultPeaDefinitionRegistry()
+	registry.SetLogger(&testLogger{})
+	testInterfaceType := goo.GetType((*testInterface)(nil))
+
+	registry.RegisterPeaDefinition("testPea", NewSimplePeaDefinition(goo.GetType(testStruct{})))
+	assert.Equal(t, []string{"testPea"}, registry.GetPeaNamesByType(testInterfaceType))
+
+	registry.RegisterPeaDefinition("anotherTestPea", NewSimplePeaDefinition(goo.GetType(newStructFunction)))
+	assert.Equal(t, []string{"testPea", "anotherTestPea"}, registry.GetPeaNamesByType(testInterfaceType))
+
+	registry.RegisterPeaDefinition("testPea", NewSimplePeaDefinition(goo.GetType(testStruct2{})))
+	assert.Equal(t, []string{"anotherTestPea"}, registry.GetPeaNamesByType(testInterfaceType))
+
+	registry.RemovePeaDefinition("anotherTestPea")
+	assert.Empty(t, registry.GetPeaNamesByType(testInterfaceType))
+
+	registry.RegisterPeaDefinition("anotherTestPea", NewSimplePeaDefinition(goo.GetType(newStructFunction)))
+	assert.Equal(t, []string{"anotherTestPea"}, registry.GetPeaNamesByType(testInterfaceType))
+
+	registry.SetTypeMatcher(nothingMatcher{})
+	assert.Empty(t, registry.GetPeaNamesByType(testInterfaceType))
+}
+
+func TestDefaultPeaDefinitionRegistry_GetPeaNamesByTypeReturnsCopy(t *testing.T) {
+	registry := NewDefaultPeaDefinitionRegistry()
+	registry.RegisterPeaDefinition("testPea", NewSimplePeaDefinition(goo.GetType(testStruct{})))
+
+	peaNames := registry.GetPeaNamesByType(goo.GetType(testStruct{}))
+	peaNames[0] = "modifiedPea"
+	assert.Equal(t, []string{"testPea"}, registry.GetPeaNamesByType(goo.GetType(testStruct{})))
+}
+
+func newBenchmarkPeaDefinitionRegistry() *DefaultPeaDefinitionRegistry {
+	registry := NewDefaultPeaDefinitionRegistry()
+	peaTypes := []goo.Type{
+		goo.GetType(testStruct{}),
+		goo.GetType(testStruct2{}),
+		goo.GetType(newStructFunction),
+		goo.GetType(newAStruct),
+		goo.GetType(newBStruct),
+	}
+
+	for index := 0; index < 1500; index++ {
+		registry.RegisterPeaDefinition("pea"+strconv.Itoa(index), NewSimplePeaDefinition(peaTypes[index%len(peaTypes)]))
+	}
+	return registry
+}
+
+func BenchmarkDefaultPeaDefinitionRegistry_GetPeaNamesByType(b *testing.B) {
+	registry := newBenchmarkPeaDefinitionRegistry()
+	testInterfaceType := goo.GetType((*testInterface)(nil))
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		registry.GetPeaNamesByType(testInterfaceType)
+	}
+}
+
+func BenchmarkDefaultPeaDefinitionRegistry_GetPeaNamesByTypeWithoutTypeIndex(b *testing.B) {
+	registry := newBenchmarkPeaDefinitionRegistry()
+	testInterfaceType := goo.GetType((*testInterface)(nil))
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		registry.invalidateTypeIndex()
+		registry.GetPeaNamesByType(testInterfaceType)
+	}
+}
+
+func TestSimplePeaDefinition_Metadata(t *testing.T) {
+	definition := NewSimplePeaDefinition(goo.GetType(testStruct{}),
+		WithAttribute("path", "/users"),
+		WithTags("http-route", "admin", "http-route"),
+		WithDescription("Handles the user requests"),
+		WithRole(InfrastructureRole))
+
+	assert.Equal(t, map[string]interface{}{"path": "/users"}, definition.GetAttributes())
+	assert.Equal(t, "/users", definition.GetAttribute("path"))
+	assert.Nil(t, definition.GetAttribute("method"))
+	assert.Equal(t, []string{"http-route", "admin"}, definition.GetTags())
+	assert.True(t, definition.HasTag("admin"))
+	assert.False(t, definition.HasTag("public"))
+	assert.Equal(t, "Handles the user requests", definition.GetDescription())
+	assert.Equal(t, InfrastructureRole, definition.GetRole())
+	assert.Equal(t, ApplicationRole, NewSimplePeaDefinition(goo.GetType(testStruct{})).GetRole())
+}
+
+func TestDefaultPeaDefinitionRegistry_GetPeaNamesByTagAndAttribute(t *testing.T) {
+	registry := NewDefaultPeaDefinitionRegistry()
+	registry.RegisterPeaDefinition("routeTemplate", NewSimplePeaDefinition(goo.GetType(testStruct{}),
+		WithAbstract(),
+		WithTags("http-route"),
+		WithAttribute("method", "GET")))
+	registry.RegisterPeaDefinition("userRoute", NewSimplePeaDefinition(nil,
+		WithParent("routeTemplate"),
+		WithTags("admin"),
+		WithAttribute("path", "/users")))
+	registry.RegisterPeaDefinition("orderRoute", NewSimplePeaDefinition(goo.GetType(testStruct{}),
+		WithTags("http-route"),
+		WithAttribute("path", "/orders"),
+		WithAttribute("method", "POST")))
+
+	assert.Equal(t, []string{"userRoute", "orderRoute"}, registry.GetPeaNamesByTag("http-route"))
+	assert.Equal(t, []string{"userRoute"}, registry.GetPeaNamesByTag("admin"))
+	assert.Empty(t, registry.GetPeaNamesByTag("public"))
+	assert.Equal(t, []string{"userRoute"}, registry.GetPeaNamesByAttribute("method", "GET"))
+	assert.Equal(t, []string{"orderRoute"}, registry.GetPeaNamesByAttribute("path", "/orders"))
+	assert.Empty(t, registry.GetPeaNamesByAttribute("path", "/products"))
+}
+
+func TestSimplePeaDefinition_GetSource(t *testing.T) {
+	definition := NewSimplePeaDefinition(goo.GetType(testStruct{}))
+	assert.Regexp(t, `definition_test\.go:\d+$`, definition.GetSource())
+
+	definition = NewSimplePeaDefinition(goo.GetType(testStruct{}), WithSource("config.go:12"))
+	assert.Equal(t, "config.go:12", definition.GetSource())
+}
@@ -0,0 +1,135 @@
+package peas
+
+import (
+	"sync"
+)
+
+type peaDependencyRegistry struct {
+	dependencies map[string][]string
+	mu           sync.RWMutex
+}
+
+func newPeaDependencyRegistry() *peaDependencyRegistry {
+	return &peaDependencyRegistry{
+		dependencies: make(map[string][]string, 0),
+		mu:           sync.RWMutex{},
+	}
+}
+
+func (registry *peaDependencyRegistry) registerDependency(peaName string, dependencyName string) {
+	if peaName == dependencyName {
+		return
+	}
+
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+	for _, name := range registry.dependencies[peaName] {
+		if name == dependencyName {
+			return
+		}
+	}
+	registry.dependencies[peaName] = append(registry.dependencies[peaName], dependencyName)
+}
+
+func (registry *peaDependencyRegistry) getDependencies(peaName string) []string {
+	registry.mu.RLock()
+	defer registry.mu.RUnlock()
+	return append(make([]string, 0), registry.dependencies[peaName]...)
+}
+
+func (registry *peaDependencyRegistry) removePea(peaName string) {
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+	delete(registry.dependencies, peaName)
+	for name, dependencies := range registry.dependencies {
+		registry.dependencies[name] = removeString(dependencies, peaName)
+	}
+}
+
+// sortByDependencies returns the given pea names ordered so that every pea comes after
+// the peas it depends on, directly or through peas which are not in the given list.
+func (registry *peaDependencyRegistry) sortByDependencies(peaNames []string) []string {
+	registry.mu.RLock()
+	defer registry.mu.RUnlock()
+
+	requested := make(map[string]bool, len(peaNames))
+	for _, peaName := range peaNames {
+		requested[peaName] = true
+	}
+
+	sortedNames := make([]string, 0, len(peaNames))
+	visited := make(map[string]bool, 0)
+
+	var visit func(peaName string)
+	visit = func(peaName string) {
+		if visited[peaName] {
+			return
+		}
+		visited[peaName] = true
+		for _, dependencyName := range registry.dependencies[peaName] {
+			visit(dependencyName)
+		}
+		if requested[peaName] {
+			sortedNames = append(sortedNames, peaName)
+		}
+	}
+
+	for _, peaName := range peaNames {
+		visit(peaName)
+	}
+	return sortedNames
+}
+
+func removeString(values []string, value string) []string {
+	result := make([]string, 0, len(values))
+	for _, item := range values {
+		if item != value {
+			result = append(result, item)
+		}
+	}
+	return result
+}
+
+func containsString(values []string, value string) bool {
+	for _, item := range values {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
+
+func (factory DefaultPeaFactory) findDependsOnCycle(peaName string) []string {
+	path := make([]string, 0)
+	visited := make(map[string]bool, 0)
+
+	var visit func(peaName string) []string
+	visit = func(peaName string) []string {
+		for index, name := range path {
+			if name == peaName {
+				return append(append(make([]string, 0), path[index:]...), peaName)
+			}
+		}
+
+		if visited[peaName] {
+			return nil
+		}
+
+		peaDefinition := factory.getMergedPeaDefinition(peaName)
+		if peaDefinition == nil {
+			return nil
+		}
+
+		path = append(path, peaName)
+		for _, dependencyName := range peaDefinition.GetDependsOn() {
+			if cycle := visit(factory.CanonicalName(dependencyName)); cycle != nil {
+				return cycle
+			}
+		}
+		path = path[:len(path)-1]
+		visited[peaName] = true
+		return nil
+	}
+
+	return visit(peaName)
+}
@@ -0,0 +1,27 @@
+package peas
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestPeaDependencyRegistry_RegisterDependency(t *testing.T) {
+	registry := newPeaDependencyRegistry()
+	registry.registerDependency("a", "b")
+	registry.registerDependency("a", "b")
+	registry.registerDependency("a", "a")
+	registry.registerDependency("a", "c")
+
+	assert.Equal(t, []string{"b", "c"}, registry.getDependencies("a"))
+	assert.Empty(t, registry.getDependencies("b"))
+}
+
+func TestPeaDependencyRegistry_SortByDependencies(t *testing.T) {
+	registry := newPeaDependencyRegistry()
+	registry.registerDependency("a", "b")
+	registry.registerDependency("b", "c")
+	registry.registerDependency("c", "d")
+
+	assert.Equal(t, []string{"d", "b", "a"}, registry.sortByDependencies([]string{"a", "b", "d"}))
+	assert.Equal(t, []string{"x", "d"}, registry.sortByDependencies([]string{"x", "d"}))
+}
@@ -0,0 +1,57 @@
+package peas
+
+import (
+	"os"
+	"strings"
+	"sync"
+)
+
+type Environment interface {
+	GetProperty(key string) (string, bool)
+	ContainsProperty(key string) bool
+}
+
+// StandardEnvironment resolves the properties set on it first, and then the OS environment variables.
+// A property like "messaging.broker-url" can also be given by the environment variable MESSAGING_BROKER_URL.
+type StandardEnvironment struct {
+	properties map[string]string
+	mu         sync.RWMutex
+}
+
+func NewStandardEnvironment() *StandardEnvironment {
+	return &StandardEnvironment{
+		properties: make(map[string]string, 0),
+		mu:         sync.RWMutex{},
+	}
+}
+
+func (environment *StandardEnvironment) SetProperty(key string, value string) {
+	environment.mu.Lock()
+	environment.properties[key] = value
+	environment.mu.Unlock()
+}
+
+func (environment *StandardEnvironment) GetProperty(key string) (string, bool) {
+	environment.mu.RLock()
+	value, ok := environment.properties[key]
+	environment.mu.RUnlock()
+
+	if ok {
+		return value, true
+	}
+
+	if value, ok = os.LookupEnv(key); ok {
+		return value, true
+	}
+
+	return os.LookupEnv(toEnvironmentVariableName(key))
+}
+
+func (environment *StandardEnvironment) ContainsProperty(key string) bool {
+	_, ok := environment.GetProperty(key)
+	return ok
+}
+
+func toEnvironmentVariableName(key string) string {
+	return strings.ToUpper(strings.NewReplacer(".", "_", "-", "_").Replace(key))
+}
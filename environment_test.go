@@ -0,0 +1,27 @@
+package peas
+
+import (
+	"github.com/stretchr/testify/assert"
+	"os"
+	"testing"
+)
+
+func TestStandardEnvironment_GetProperty(t *testing.T) {
+	os.Setenv("MESSAGING_BROKER_URL", "amqp://broker")
+	defer os.Unsetenv("MESSAGING_BROKER_URL")
+
+	environment := NewStandardEnvironment()
+	value, ok := environment.GetProperty("messaging.broker-url")
+	assert.True(t, ok)
+	assert.Equal(t, "amqp://broker", value)
+
+	environment.SetProperty("messaging.broker-url", "amqp://localhost")
+	value, ok = environment.GetProperty("messaging.broker-url")
+	assert.True(t, ok)
+	assert.Equal(t, "amqp://localhost", value)
+
+	_, ok = environment.GetProperty("messaging.missing")
+	assert.False(t, ok)
+	assert.False(t, environment.ContainsProperty("messaging.missing"))
+	assert.True(t, environment.ContainsProperty("MESSAGING_BROKER_URL"))
+}
@@ -1,8 +1,11 @@
 package peas
 
 import (
+	"errors"
+	"github.com/procyon-projects/goo"
 	"github.com/stretchr/testify/assert"
 	"testing"
+	"time"
 )
 
 func TestPeaPreparationError_Error(t *testing.T) {
@@ -34,3 +37,34 @@ func TestPeaInPreparationError_GetMessage(t *testing.T) {
 	err := NewPeaInPreparationError("test-pea")
 	assert.Equal(t, "Pea is currently in preparation, maybe it has got circular dependency cycle", err.GetMessage())
 }
+
+func TestPeaInitializationTimeoutError_Error(t *testing.T) {
+	err := NewPeaInitializationTimeoutError("test-pea", 5*time.Second)
+	assert.Equal(t, "test-pea : Pea could not be initialized within 5s", err.Error())
+	assert.Equal(t, "test-pea", err.GetPeaName())
+	assert.Equal(t, 5*time.Second, err.GetTimeout())
+}
+
+func TestPeaDefinitionOverrideError_Error(t *testing.T) {
+	existingDefinition := NewSimplePeaDefinition(goo.GetType(testStruct{}))
+	newDefinition := NewSimplePeaDefinition(goo.GetType(testStruct2{}))
+	err := NewPeaDefinitionOverrideError("test-pea", existingDefinition, "a.go:10", newDefinition, "b.go:20")
+	assert.Equal(t, "test-pea : Pea definition 'testStruct' registered at a.go:10 is overridden by pea definition 'testStruct2' registered at b.go:20", err.Error())
+	assert.Equal(t, "test-pea", err.GetPeaName())
+	assert.Equal(t, existingDefinition, err.GetExistingDefinition())
+	assert.Equal(t, "a.go:10", err.GetExistingLocation())
+	assert.Equal(t, newDefinition, err.GetNewDefinition())
+	assert.Equal(t, "b.go:20", err.GetNewLocation())
+}
+
+func TestPeaCreationError_Error(t *testing.T) {
+	cause := NewPeaInitializationTimeoutError("test-pea", 5*time.Second)
+	err := NewPeaCreationError("test-pea", "config.go:12", cause)
+	assert.Equal(t, "test-pea : pea defined at config.go:12 could not be created : test-pea : Pea could not be initialized within 5s", err.Error())
+	assert.Equal(t, "config.go:12", err.GetSource())
+	assert.Equal(t, cause, err.GetCause())
+
+	var timeoutErr PeaInitializationTimeoutError
+	assert.True(t, errors.As(err, &timeoutErr))
+	assert.Equal(t, 5*time.Second, timeoutErr.GetTimeout())
+}
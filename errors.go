@@ -1,5 +1,10 @@
 package peas
 
+import (
+	"strings"
+	"time"
+)
+
 type PeaPreparationError struct {
 	peaName string
 	message string
@@ -32,3 +37,114 @@ func NewPeaInPreparationError(peaName string) PeaInPreparationError {
 		),
 	}
 }
+
+type PeaInitializationTimeoutError struct {
+	PeaPreparationError
+	timeout time.Duration
+}
+
+func NewPeaInitializationTimeoutError(peaName string, timeout time.Duration) PeaInitializationTimeoutError {
+	return PeaInitializationTimeoutError{
+		NewPeaPreparationError(peaName,
+			"Pea could not be initialized within "+timeout.String(),
+		),
+		timeout,
+	}
+}
+
+func (error PeaInitializationTimeoutError) GetTimeout() time.Duration {
+	return error.timeout
+}
+
+type PeaCreationError struct {
+	PeaPreparationError
+	source string
+	cause  error
+}
+
+func NewPeaCreationError(peaName string, source string, cause error) PeaCreationError {
+	return PeaCreationError{
+		NewPeaPreparationError(peaName,
+			"pea defined at "+source+" could not be created : "+cause.Error(),
+		),
+		source,
+		cause,
+	}
+}
+
+func (error PeaCreationError) GetSource() string {
+	return error.source
+}
+
+func (error PeaCreationError) GetCause() error {
+	return error.cause
+}
+
+func (error PeaCreationError) Unwrap() error {
+	return error.cause
+}
+
+type PeaDefinitionOverrideError struct {
+	peaName            string
+	existingDefinition PeaDefinition
+	existingLocation   string
+	newDefinition      PeaDefinition
+	newLocation        string
+}
+
+func NewPeaDefinitionOverrideError(peaName string,
+	existingDefinition PeaDefinition,
+	existingLocation string,
+	newDefinition PeaDefinition,
+	newLocation string) PeaDefinitionOverrideError {
+	return PeaDefinitionOverrideError{
+		peaName,
+		existingDefinition,
+		existingLocation,
+		newDefinition,
+		newLocation,
+	}
+}
+
+func (error PeaDefinitionOverrideError) GetPeaName() string {
+	return error.peaName
+}
+
+func (error PeaDefinitionOverrideError) GetExistingDefinition() PeaDefinition {
+	return error.existingDefinition
+}
+
+func (error PeaDefinitionOverrideError) GetExistingLocation() string {
+	return error.existingLocation
+}
+
+func (error PeaDefinitionOverrideError) GetNewDefinition() PeaDefinition {
+	return error.newDefinition
+}
+
+func (error PeaDefinitionOverrideError) GetNewLocation() string {
+	return error.newLocation
+}
+
+func (error PeaDefinitionOverrideError) Error() string {
+	return error.peaName + " : Pea definition '" + error.existingDefinition.GetTypeName() + "' registered at " + error.existingLocation +
+		" is overridden by pea definition '" + error.newDefinition.GetTypeName() + "' registered at " + error.newLocation
+}
+
+type PeaDefinitionValidationError struct {
+	PeaPreparationError
+	problems []string
+}
+
+func NewPeaDefinitionValidationError(peaName string, problems []string) PeaDefinitionValidationError {
+	return PeaDefinitionValidationError{
+		NewPeaPreparationError(peaName,
+			"Pea definition is not valid : "+strings.Join(problems, ", "),
+		),
+		problems,
+	}
+}
+
+func (error PeaDefinitionValidationError) GetProblems() []string {
+	return error.problems
+}
@@ -1,10 +1,13 @@
 package peas
 
 import (
+	"context"
 	"errors"
 	"github.com/procyon-projects/goo"
 	"reflect"
+	"strings"
 	"sync"
+	"time"
 )
 
 type PeaFactory interface {
@@ -15,24 +18,133 @@ type PeaFactory interface {
 	ContainsPea(name string) bool
 }
 
+var contextType = reflect.TypeOf((*context.Context)(nil)).Elem()
+
+type PeaFactoryOption func(factory *DefaultPeaFactory)
+
 type DefaultPeaFactory struct {
 	SharedPeaRegistry
 	PeaDefinitionRegistry
-	peaProcessors *PeaProcessors
-	readableTypes map[string]goo.Type
-	excludedTypes map[string]goo.Type
-	muScopes      *sync.RWMutex
+	peaProcessors           *PeaProcessors
+	readableTypes           map[string]goo.Type
+	excludedTypes           map[string]goo.Type
+	muScopes                *sync.RWMutex
+	dependencies            *peaDependencyRegistry
+	lifecyclePhaseTimeout   time.Duration
+	initializationTimeout   time.Duration
+	preInstantiationWorkers int
+	parent                  PeaFactory
+	typeMatcher             TypeMatcher
+	mergedDefinitions       *mergedPeaDefinitionCache
+	environment             Environment
+	conditionReport         *ConditionEvaluationReport
+	conditionState          *conditionEvaluationState
+	profiles                *profileRegistry
+}
+
+type peaTypeResolver interface {
+	GetPeaNamesByType(typ goo.Type) []string
 }
 
-func NewDefaultPeaFactory() DefaultPeaFactory {
-	return DefaultPeaFactory{
+type dependencyResolver interface {
+	resolveDependency(parameterType goo.Type) ([]string, []interface{}, error)
+	getAutowireCandidateNames(typ goo.Type) []string
+	isPrimaryPea(name string) bool
+}
+
+func NewDefaultPeaFactory(options ...PeaFactoryOption) DefaultPeaFactory {
+	factory := DefaultPeaFactory{
 		SharedPeaRegistry:     NewDefaultSharedPeaRegistry(),
 		PeaDefinitionRegistry: NewDefaultPeaDefinitionRegistry(),
 		peaProcessors:         NewPeaProcessors(),
 		readableTypes:         make(map[string]goo.Type, 0),
 		excludedTypes:         make(map[string]goo.Type, 0),
 		muScopes:              &sync.RWMutex{},
+		dependencies:          newPeaDependencyRegistry(),
+		lifecyclePhaseTimeout: DefaultLifecyclePhaseTimeout,
+		mergedDefinitions:     newMergedPeaDefinitionCache(),
+		environment:           NewStandardEnvironment(),
+		conditionReport:       newConditionEvaluationReport(),
+		conditionState:        &conditionEvaluationState{},
+		profiles:              newProfileRegistry(),
+	}
+
+	for _, option := range options {
+		option(&factory)
 	}
+
+	if factory.typeMatcher == nil {
+		factory.typeMatcher = NewDefaultTypeMatcher()
+	} else {
+		if registry, ok := factory.SharedPeaRegistry.(typeMatcherAware); ok {
+			registry.SetTypeMatcher(factory.typeMatcher)
+		}
+		if registry, ok := factory.PeaDefinitionRegistry.(typeMatcherAware); ok {
+			registry.SetTypeMatcher(factory.typeMatcher)
+		}
+	}
+
+	return factory
+}
+
+func NewChildPeaFactory(parent PeaFactory, options ...PeaFactoryOption) DefaultPeaFactory {
+	factory := NewDefaultPeaFactory(options...)
+	factory.parent = parent
+	return factory
+}
+
+func WithSharedPeaRegistry(registry SharedPeaRegistry) PeaFactoryOption {
+	return func(factory *DefaultPeaFactory) {
+		factory.SharedPeaRegistry = registry
+	}
+}
+
+func WithPeaDefinitionRegistry(registry PeaDefinitionRegistry) PeaFactoryOption {
+	return func(factory *DefaultPeaFactory) {
+		factory.PeaDefinitionRegistry = registry
+	}
+}
+
+func WithDefaultInitializationTimeout(timeout time.Duration) PeaFactoryOption {
+	return func(factory *DefaultPeaFactory) {
+		factory.initializationTimeout = timeout
+	}
+}
+
+func WithParallelPreInstantiation(workerCount int) PeaFactoryOption {
+	return func(factory *DefaultPeaFactory) {
+		factory.preInstantiationWorkers = workerCount
+	}
+}
+
+func WithLifecyclePhaseTimeout(timeout time.Duration) PeaFactoryOption {
+	return func(factory *DefaultPeaFactory) {
+		factory.lifecyclePhaseTimeout = timeout
+	}
+}
+
+func WithTypeMatcher(matcher TypeMatcher) PeaFactoryOption {
+	return func(factory *DefaultPeaFactory) {
+		factory.typeMatcher = matcher
+	}
+}
+
+func WithEnvironment(environment Environment) PeaFactoryOption {
+	return func(factory *DefaultPeaFactory) {
+		factory.environment = environment
+	}
+}
+
+func (factory DefaultPeaFactory) GetEnvironment() Environment {
+	return factory.environment
+}
+
+func (factory DefaultPeaFactory) GetTypeMatcher() TypeMatcher {
+	return factory.typeMatcher
+}
+
+func (factory DefaultPeaFactory) GetParentPeaFactory() PeaFactory {
+	return factory.parent
 }
 
 func (factory DefaultPeaFactory) GetPea(name string) (interface{}, error) {
@@ -52,7 +164,68 @@ func (factory DefaultPeaFactory) GetPeaByType(typ goo.Type) (interface{}, error)
 }
 
 func (factory DefaultPeaFactory) ContainsPea(name string) bool {
-	return factory.ContainsSharedPea(name)
+	name = factory.CanonicalName(name)
+	if factory.ContainsSharedPea(name) {
+		return true
+	}
+
+	if factory.parent != nil && !factory.isLocalPea(name) {
+		return factory.parent.ContainsPea(name)
+	}
+	return false
+}
+
+func (factory DefaultPeaFactory) GetPeaNamesByType(typ goo.Type) []string {
+	peaNames := factory.filterActivePeaNames(factory.PeaDefinitionRegistry.GetPeaNamesByType(typ))
+	if len(peaNames) != 0 || factory.parent == nil {
+		return peaNames
+	}
+
+	if parent, ok := factory.parent.(peaTypeResolver); ok {
+		return parent.GetPeaNamesByType(typ)
+	}
+	return peaNames
+}
+
+func (factory DefaultPeaFactory) getAutowireCandidateNames(typ goo.Type) []string {
+	candidateNames := factory.getLocalAutowireCandidateNames(typ)
+	if len(candidateNames) != 0 || factory.parent == nil {
+		return candidateNames
+	}
+
+	if parent, ok := factory.parent.(dependencyResolver); ok {
+		return parent.getAutowireCandidateNames(typ)
+	} else if parent, ok := factory.parent.(peaTypeResolver); ok {
+		return parent.GetPeaNamesByType(typ)
+	}
+	return candidateNames
+}
+
+func (factory DefaultPeaFactory) getLocalAutowireCandidateNames(typ goo.Type) []string {
+	candidateNames := make([]string, 0)
+	for _, peaName := range factory.PeaDefinitionRegistry.GetPeaNamesByType(typ) {
+		peaDefinition := factory.getMergedPeaDefinition(peaName)
+		if peaDefinition != nil && peaDefinition.IsAutowireCandidate() && factory.isActivePeaDefinition(peaDefinition) {
+			candidateNames = append(candidateNames, peaName)
+		}
+	}
+	return candidateNames
+}
+
+func (factory DefaultPeaFactory) isLocalPea(name string) bool {
+	if factory.ContainsSharedPea(name) {
+		return true
+	}
+	return factory.ContainsPeaDefinition(name) && factory.isActivePeaDefinition(factory.getMergedPeaDefinition(name))
+}
+
+func (factory DefaultPeaFactory) getParentPea(name string, requiredType goo.Type, args ...interface{}) (interface{}, error) {
+	if args != nil {
+		return factory.parent.GetPeaByNameAndArgs(name, args...)
+	} else if requiredType != nil {
+		return factory.parent.GetPeaByNameAndType(name, requiredType)
+	}
+	return factory.parent.GetPea(name)
 }
 
 func (factory DefaultPeaFactory) getPeaWith(name string, requiredType goo.Type, args ...interface{}) (interface{}, error) {
@@ -60,31 +233,41 @@ func (factory DefaultPeaFactory) getPeaWith(name string, requiredType goo.Type,
 		return nil, errors.New("one of the pea name or type must not be nil at least")
 	}
 
-	if name == "" {
-		candidatePeaNames := factory.GetPeaNamesByType(requiredType)
+	factory.evaluatePendingConditions()
+
+	if name != "" {
+		name = factory.CanonicalName(name)
+	} else {
+		candidatePeaNames := factory.getAutowireCandidateNames(requiredType)
 		candidatePeaCount := len(candidatePeaNames)
 		if candidatePeaCount > 1 {
-			return nil, errors.New("there is more than one candidate pea definition for the required type, it cannot be distinguished : " + requiredType.GetPackageFullName())
+			primaryPeaName, err := factory.determinePrimaryCandidate(candidatePeaNames)
+			if err != nil {
+				return nil, err
+			} else if primaryPeaName == "" {
+				return nil, errors.New("there is more than one candidate pea definition for the required type, it cannot be distinguished : " +
+					requiredType.GetPackageFullName() + " (candidates : " + factory.describeCandidates(candidatePeaNames) + ")")
+			}
+			candidatePeaNames = []string{primaryPeaName}
 		} else if candidatePeaCount == 0 {
 			return nil, errors.New("pea definition couldn't be found for the required type : " + requiredType.GetPackageFullName())
 		}
 		name = candidatePeaNames[0]
 	}
 
+	if factory.parent != nil && !factory.isLocalPea(name) {
+		return factory.getParentPea(name, requiredType, args...)
+	}
+
 	sharedPea := factory.GetSharedPea(name)
 	if sharedPea != nil && args == nil {
 
 		if requiredType != nil {
-			peaDefinition := factory.GetPeaDefinition(name)
-
-			var peaType goo.Type
-			if peaDefinition == nil {
-				peaType = goo.GetType(sharedPea)
-			} else {
-				peaType = peaDefinition.GetPeaType()
-			}
+			peaDefinition := factory.getMergedPeaDefinition(name)
 
-			if factory.matches(peaType, requiredType) {
+			if peaDefinition == nil && containsString(factory.GetSharedPeaNamesByType(requiredType), name) {
+				return sharedPea, nil
+			} else if peaDefinition != nil && matchesDefinition(factory.typeMatcher, peaDefinition, requiredType) {
 				return sharedPea, nil
 			}
 
@@ -94,18 +277,33 @@ func (factory DefaultPeaFactory) getPeaWith(name string, requiredType goo.Type,
 		return sharedPea, nil
 	}
 
-	peaDefinition := factory.GetPeaDefinition(name)
-	if peaDefinition == nil {
-		return nil, errors.New("pea definition couldn't be found : " + name)
+	peaDefinition, err := factory.GetMergedPeaDefinition(name)
+	if err != nil {
+		return nil, err
+	}
+
+	if peaDefinition.IsAbstract() {
+		return nil, errors.New("abstract pea definition cannot be instantiated : " + name)
+	} else if !factory.isActivePeaDefinition(peaDefinition) {
+		return nil, errors.New("pea definition is not active for the current profiles : " + name)
+	} else if peaDefinition.GetPeaType() == nil {
+		return nil, errors.New("pea definition type couldn't be resolved : " + name)
 	}
 
-	if requiredType != nil && !factory.matches(peaDefinition.GetPeaType(), requiredType) {
+	if requiredType != nil && !matchesDefinition(factory.typeMatcher, peaDefinition, requiredType) {
 		return nil, errors.New("pea definition type does not match the required type")
 	}
 
+	if err := factory.createDependsOnPeas(name, peaDefinition); err != nil {
+		return nil, err
+	}
+
 	if SharedScope == peaDefinition.GetScope() {
 		instance, err := factory.GetSharedPeaWithObjectFunc(name, func() (instance interface{}, err error) {
 			instance, err = factory.createPea(name, peaDefinition, args)
+			if err != nil {
+				err = NewPeaCreationError(name, peaDefinition.GetSource(), err)
+			}
 			return
 		})
 		return instance, err
@@ -113,82 +311,214 @@ func (factory DefaultPeaFactory) getPeaWith(name string, requiredType goo.Type,
 		peaType := peaDefinition.GetPeaType()
 
 		if peaType.IsFunction() {
-
-			fun := peaType.ToFunctionType()
-			if fun.GetFunctionReturnTypeCount() == 1 {
-				peaType = fun.GetFunctionReturnTypes()[0]
-			} else {
-				return nil, errors.New("pea must have only one return type")
+			if _, ok := getConstructorReturnType(peaType.ToFunctionType()); !ok {
+				return nil, errors.New("pea must have only one return type besides an error")
 			}
-
 		}
 
-		instance, err := factory.createPeaInstance(name, peaType, args)
-		return instance, err
+		instance, err := factory.createPeaInstance(name, peaDefinition, peaType, args)
+		if err != nil {
+			return nil, NewPeaCreationError(name, peaDefinition.GetSource(), err)
+		}
+		return instance, nil
 	}
 
 	return nil, errors.New("instance couldn't be created")
 }
 
-func (factory DefaultPeaFactory) matches(peaType goo.Type, requiredType goo.Type) bool {
-	if peaType.IsFunction() {
-		fun := peaType.ToFunctionType()
-		if fun.GetFunctionReturnTypeCount() == 1 {
-			peaType = fun.GetFunctionReturnTypes()[0]
-		}
+func (factory DefaultPeaFactory) createDependsOnPeas(name string, definition PeaDefinition) error {
+	if len(definition.GetDependsOn()) == 0 {
+		return nil
+	}
+
+	if cycle := factory.findDependsOnCycle(name); cycle != nil {
+		return NewPeaPreparationError(name, "circular dependency cycle : "+strings.Join(cycle, " -> "))
 	}
-	match := false
-	if peaType.Equals(requiredType) || peaType.GetGoType().ConvertibleTo(requiredType.GetGoType()) {
-		match = true
-	} else if requiredType.IsInterface() && peaType.ToStructType().Implements(requiredType.ToInterfaceType()) {
-		match = true
-	} else if requiredType.IsStruct() && peaType.ToStructType().EmbeddedStruct(requiredType.ToStructType()) {
-		match = true
+
+	for _, dependencyName := range definition.GetDependsOn() {
+		dependencyName = factory.CanonicalName(dependencyName)
+		_, err := factory.GetPea(dependencyName)
+		if err != nil {
+			return NewPeaPreparationError(name, "depends-on pea '"+dependencyName+"' could not be created : "+err.Error())
+		}
+
+		if definition.GetScope() == SharedScope {
+			factory.dependencies.registerDependency(name, dependencyName)
+		}
 	}
-	return match
+	return nil
 }
 
 func (factory DefaultPeaFactory) createPea(name string, definition PeaDefinition, args []interface{}) (interface{}, error) {
-	instance, err := factory.createPeaInstance(name, definition.GetPeaType(), args)
+	instance, err := factory.createPeaInstance(name, definition, definition.GetPeaType(), args)
 	if err == nil && definition.GetScope() == SharedScope {
-		err = factory.RegisterSharedPea(name, instance)
+		err = factory.RegisterSharedPeaAs(name, instance, definition.GetExposedTypes()...)
 	}
 	return instance, err
 }
 
-func (factory DefaultPeaFactory) createPeaInstance(name string, typ goo.Type, args []interface{}) (result interface{}, error error) {
+func (factory DefaultPeaFactory) createPeaInstance(name string, definition PeaDefinition, typ goo.Type, args []interface{}) (interface{}, error) {
 	var instance interface{}
+	var err error
 	if typ.IsFunction() {
 		constructorFunction := typ.ToFunctionType()
 		parameterCount := constructorFunction.GetFunctionParameterCount()
 
 		if parameterCount != 0 && args == nil {
 			parameterTypes := constructorFunction.GetFunctionParameterTypes()
-			resolvedArguments := factory.createArgumentArray(name, parameterTypes)
-			instance, error = CreateInstance(typ, resolvedArguments)
+			resolvedArguments, argumentErr := factory.createArgumentArray(name, definition, parameterTypes)
+			if argumentErr != nil {
+				return nil, argumentErr
+			}
+			instance, err = factory.invokeWithTimeout(name, definition, func(ctx context.Context) (interface{}, error) {
+				return createInstance(typ, factory.putContextArguments(ctx, definition, parameterTypes, resolvedArguments))
+			})
 		} else if (parameterCount == 0 && args == nil) || (args != nil && parameterCount == len(args)) {
-			instance, error = CreateInstance(typ, args)
+			instance, err = factory.invokeWithTimeout(name, definition, func(ctx context.Context) (interface{}, error) {
+				return createInstance(typ, args)
+			})
 		} else {
-			error = errors.New("argument count does not match with the parameter count which constructor function has go")
+			err = errors.New("argument count does not match with the parameter count which constructor function has go")
 		}
 
 	} else {
-		instance, error = CreateInstance(typ, nil)
+		instance, err = CreateInstance(typ, nil)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	instance, err = factory.initializePea(name, definition, instance)
+	if err != nil {
+		return nil, err
+	}
+	return instance, nil
+}
+
+// invokeWithTimeout calls the given function with a context bounded by the initialization timeout of the pea.
+// When the timeout expires, PeaInitializationTimeoutError is returned without waiting for the function,
+// and its result is discarded. So the function should return as soon as the context is done.
+func (factory DefaultPeaFactory) invokeWithTimeout(name string,
+	definition PeaDefinition,
+	fun func(ctx context.Context) (interface{}, error)) (interface{}, error) {
+	timeout := factory.getInitializationTimeout(definition)
+	if timeout <= 0 {
+		return fun(context.Background())
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	type invocationResult struct {
+		result   interface{}
+		err      error
+		panicErr interface{}
+	}
+
+	done := make(chan invocationResult, 1)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				done <- invocationResult{panicErr: r}
+			}
+		}()
+		result, err := fun(ctx)
+		done <- invocationResult{result: result, err: err}
+	}()
+
+	var result invocationResult
+	select {
+	case result = <-done:
+	case <-ctx.Done():
+		select {
+		case result = <-done:
+		default:
+			return nil, NewPeaInitializationTimeoutError(name, timeout)
+		}
 	}
 
-	if error != nil {
-		return
+	if result.panicErr != nil {
+		panic(result.panicErr)
 	}
 
-	return factory.initializePea(name, instance)
+	if result.err != nil && ctx.Err() == context.DeadlineExceeded {
+		return nil, NewPeaInitializationTimeoutError(name, timeout)
+	}
+	return result.result, result.err
+}
+
+func (factory DefaultPeaFactory) getInitializationTimeout(definition PeaDefinition) time.Duration {
+	if definition != nil && definition.GetInitializationTimeout() == NoInitializationTimeout {
+		return 0
+	} else if definition != nil && definition.GetInitializationTimeout() > 0 {
+		return definition.GetInitializationTimeout()
+	}
+	return factory.initializationTimeout
 }
 
-func (factory DefaultPeaFactory) createArgumentArray(name string, parameterTypes []goo.Type) []interface{} {
+func (factory DefaultPeaFactory) putContextArguments(ctx context.Context,
+	definition PeaDefinition,
+	parameterTypes []goo.Type,
+	argumentArray []interface{}) []interface{} {
+	for parameterIndex, parameterType := range parameterTypes {
+		if parameterType.GetGoType() != contextType {
+			continue
+		}
+
+		if definition != nil {
+			if _, ok := definition.GetArguments()[parameterIndex]; ok {
+				continue
+			}
+		}
+		argumentArray[parameterIndex] = ctx
+	}
+	return argumentArray
+}
+
+func (factory DefaultPeaFactory) createArgumentArray(name string,
+	definition PeaDefinition,
+	parameterTypes []goo.Type) ([]interface{}, error) {
 	argumentArray := make([]interface{}, len(parameterTypes))
 	for parameterIndex, parameterType := range parameterTypes {
-		peas := factory.resolveDependency(parameterType)
+		if definition != nil {
+			if argument, ok := definition.GetArguments()[parameterIndex]; ok {
+				if err := checkArgumentType(parameterIndex, argument, parameterType); err != nil {
+					return nil, err
+				}
+				argumentArray[parameterIndex] = argument
+				continue
+			}
+		}
+
+		if parameterType.GetGoType() == contextType {
+			continue
+		}
+
+		peaNames, peas := make([]string, 0), make([]interface{}, 0)
+		if !isPredeclaredType(parameterType) {
+			var err error
+			if peaNames, peas, err = factory.resolveDependency(parameterType); err != nil {
+				return nil, err
+			}
+		}
 		peaObjectCount := len(peas)
 
+		if peaObjectCount > 1 {
+			primaryPeaName, err := factory.determinePrimaryCandidate(peaNames)
+			if err != nil {
+				return nil, err
+			}
+
+			for index, peaName := range peaNames {
+				if peaName == primaryPeaName {
+					peaNames, peas = peaNames[index:index+1], peas[index:index+1]
+					peaObjectCount = 1
+					break
+				}
+			}
+		}
+
 		if peaObjectCount == 0 {
 			instance := factory.getDefaultValue(parameterType)
 
@@ -214,43 +544,123 @@ func (factory DefaultPeaFactory) createArgumentArray(name string, parameterTypes
 				}
 			}
 
+			factory.dependencies.registerDependency(name, peaNames[0])
 			argumentArray[parameterIndex] = instance
 		} else {
-			panic("Determining which dependency is used cannot be distinguished : " + name)
+			return nil, errors.New("dependency of type '" + parameterType.GetFullName() + "' cannot be distinguished : " + name +
+				" (candidates : " + factory.describeCandidates(peaNames) + ")")
 		}
 
 	}
 
-	return argumentArray
+	return argumentArray, nil
 }
 
-func (factory DefaultPeaFactory) resolveDependency(parameterType goo.Type) []interface{} {
-	candidateProcessedMap := make(map[string]bool, 0)
-	candidates := make([]interface{}, 0)
+func (factory DefaultPeaFactory) determinePrimaryCandidate(peaNames []string) (string, error) {
+	primaryPeaNames := make([]string, 0)
+	for _, peaName := range peaNames {
+		if factory.isPrimaryPea(peaName) {
+			primaryPeaNames = append(primaryPeaNames, peaName)
+		}
+	}
+
+	if len(primaryPeaNames) > 1 {
+		return "", errors.New("there is more than one primary pea among the candidates : " + factory.describeCandidates(primaryPeaNames))
+	} else if len(primaryPeaNames) == 1 {
+		return primaryPeaNames[0], nil
+	}
+	return "", nil
+}
 
-	if parameterType.IsStruct() || parameterType.IsInterface() {
-		names := factory.GetPeaNamesByType(parameterType)
+func (factory DefaultPeaFactory) isPrimaryPea(name string) bool {
+	if factory.ContainsPeaDefinition(name) {
+		peaDefinition := factory.getMergedPeaDefinition(name)
+		return peaDefinition != nil && peaDefinition.IsPrimary()
+	} else if factory.ContainsSharedPea(name) {
+		return false
+	}
 
-		for _, name := range names {
-			candidate, err := factory.GetPea(name)
+	if parent, ok := factory.parent.(dependencyResolver); ok {
+		return parent.isPrimaryPea(name)
+	}
+	return false
+}
 
-			if err == nil {
-				candidates = append(candidates, candidate)
-				candidateProcessedMap[goo.GetType(candidate).GetFullName()] = true
-			}
+// GetSharedPeaType returns the only shared pea of the required type. It panics with the names and the definition
+// sources of the candidates if there is more than one.
+func (factory DefaultPeaFactory) GetSharedPeaType(requiredType goo.Type) interface{} {
+	peaNames := make([]string, 0)
+	instances := make([]interface{}, 0)
+	for _, peaName := range factory.GetSharedPeaNamesByType(requiredType) {
+		if instance := factory.GetSharedPea(peaName); instance != nil {
+			peaNames = append(peaNames, peaName)
+			instances = append(instances, instance)
 		}
+	}
 
+	if len(instances) > 1 {
+		panic("Instances of required type cannot be distinguished : " + requiredType.GetFullName() +
+			" (candidates : " + factory.describeCandidates(peaNames) + ")")
+	} else if len(instances) == 0 {
+		return nil
 	}
+	return instances[0]
+}
 
-	typeCandidates := factory.GetSharedPeasByType(parameterType)
-	for _, typeCandidate := range typeCandidates {
-		if _, ok := candidateProcessedMap[goo.GetType(typeCandidate).GetFullName()]; ok {
+func (factory DefaultPeaFactory) describeCandidates(peaNames []string) string {
+	descriptions := make([]string, 0, len(peaNames))
+	for _, peaName := range peaNames {
+		if peaDefinition := factory.getMergedPeaDefinition(peaName); peaDefinition != nil {
+			descriptions = append(descriptions, peaName+" defined at "+peaDefinition.GetSource())
+		} else {
+			descriptions = append(descriptions, peaName+" registered as shared pea")
+		}
+	}
+	return strings.Join(descriptions, ", ")
+}
+
+// resolveDependency returns the candidates of the given type. If one of the candidates cannot be created,
+// it returns the error instead of leaving the candidate out.
+func (factory DefaultPeaFactory) resolveDependency(parameterType goo.Type) ([]string, []interface{}, error) {
+	candidateProcessedMap := make(map[string]bool, 0)
+	candidateNames := make([]string, 0)
+	candidates := make([]interface{}, 0)
+
+	names := factory.getLocalAutowireCandidateNames(parameterType)
+	for _, name := range names {
+		candidate, err := factory.GetPea(name)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		candidateNames = append(candidateNames, name)
+		candidates = append(candidates, candidate)
+		candidateProcessedMap[name] = true
+	}
+
+	typeCandidateNames := factory.GetSharedPeaNamesByType(parameterType)
+	for _, typeCandidateName := range typeCandidateNames {
+		if _, ok := candidateProcessedMap[typeCandidateName]; ok {
+			continue
+		}
+		if peaDefinition := factory.getMergedPeaDefinition(typeCandidateName); peaDefinition != nil && !peaDefinition.IsAutowireCandidate() {
 			continue
 		}
+		typeCandidate := factory.GetSharedPea(typeCandidateName)
+		if typeCandidate == nil {
+			continue
+		}
+		candidateNames = append(candidateNames, typeCandidateName)
 		candidates = append(candidates, typeCandidate)
 	}
 
-	return candidates
+	if len(candidates) == 0 && factory.parent != nil {
+		if parent, ok := factory.parent.(dependencyResolver); ok {
+			return parent.resolveDependency(parameterType)
+		}
+	}
+
+	return candidateNames, candidates, nil
 }
 
 func (factory DefaultPeaFactory) getDefaultValue(parameterType goo.Type) interface{} {
@@ -272,15 +682,22 @@ func (factory DefaultPeaFactory) getDefaultValue(parameterType goo.Type) interfa
 	panic("Default value cannot be determined, it is not supported :" + parameterType.GetFullName())
 }
 
-func (factory DefaultPeaFactory) initializePea(name string, obj interface{}) (interface{}, error) {
+func (factory DefaultPeaFactory) initializePea(name string, definition PeaDefinition, obj interface{}) (interface{}, error) {
 	result := obj
 	var err error
+	factory.invokeAwareMethods(name, definition, result)
+
 	result, err = factory.applyPeaProcessorsBeforeInitialization(name, result)
 	if err != nil {
 		return result, err
 	}
 
-	err = factory.invokePeaInitializers(name, result)
+	_, err = factory.invokeWithTimeout(name, definition, func(ctx context.Context) (interface{}, error) {
+		if err := factory.invokePeaInitializers(ctx, name, result); err != nil {
+			return nil, err
+		}
+		return nil, factory.invokeInitMethod(ctx, definition, result)
+	})
 	if err != nil {
 		return result, err
 	}
@@ -292,6 +709,20 @@ func (factory DefaultPeaFactory) initializePea(name string, obj interface{}) (in
 	return result, nil
 }
 
+func (factory DefaultPeaFactory) invokeAwareMethods(name string, definition PeaDefinition, obj interface{}) {
+	if peaNameAware, ok := obj.(PeaNameAware); ok {
+		peaNameAware.SetPeaName(name)
+	}
+
+	if peaFactoryAware, ok := obj.(PeaFactoryAware); ok {
+		peaFactoryAware.SetPeaFactory(factory)
+	}
+
+	if peaDefinitionAware, ok := obj.(PeaDefinitionAware); ok {
+		peaDefinitionAware.SetPeaDefinition(definition)
+	}
+}
+
 func (factory DefaultPeaFactory) applyPeaProcessorsBeforeInitialization(name string, obj interface{}) (interface{}, error) {
 	result := obj
 	var err error
@@ -307,13 +738,29 @@ func (factory DefaultPeaFactory) applyPeaProcessorsBeforeInitialization(name str
 	return result, nil
 }
 
-func (factory DefaultPeaFactory) invokePeaInitializers(name string, obj interface{}) error {
-	if initializer, ok := obj.(PeaInitializer); ok {
+func (factory DefaultPeaFactory) invokePeaInitializers(ctx context.Context, name string, obj interface{}) error {
+	if initializer, ok := obj.(ContextPeaInitializer); ok {
+		return initializer.InitializePea(ctx)
+	} else if initializer, ok := obj.(PeaInitializer); ok {
 		return initializer.InitializePea()
 	}
 	return nil
 }
 
+func (factory DefaultPeaFactory) invokeInitMethod(ctx context.Context, definition PeaDefinition, obj interface{}) error {
+	if definition == nil || definition.GetInitMethod() == "" {
+		return nil
+	}
+
+	methodName := definition.GetInitMethod()
+	if _, ok := obj.(ContextPeaInitializer); ok && methodName == "InitializePea" {
+		return nil
+	} else if _, ok := obj.(PeaInitializer); ok && methodName == "InitializePea" {
+		return nil
+	}
+	return invokePeaMethod(ctx, obj, methodName)
+}
+
 func (factory DefaultPeaFactory) applyPeaProcessorsAfterInitialization(name string, obj interface{}) (interface{}, error) {
 	result := obj
 	var err error
@@ -383,15 +830,73 @@ func (factory DefaultPeaFactory) GetPeaProcessorsCount() int {
 	return factory.peaProcessors.GetProcessorsCount()
 }
 
-func (factory DefaultPeaFactory) PreInstantiateSharedPeas() {
+func (factory DefaultPeaFactory) PreInstantiateSharedPeas() error {
+	factory.evaluatePendingConditions()
+	if factory.preInstantiationWorkers > 0 {
+		return factory.preInstantiateSharedPeasInParallel()
+	}
+
 	peaNames := factory.GetPeaDefinitionNames()
 	for _, peaName := range peaNames {
-		peaDefinition := factory.GetPeaDefinition(peaName)
-		if peaDefinition != nil && factory.isExcludedType(peaDefinition.GetPeaType()) {
+		peaDefinition := factory.getMergedPeaDefinition(peaName)
+		if !factory.isEagerSharedPea(peaDefinition) {
 			continue
 		}
-		factory.GetPea(peaName)
+
+		_, err := factory.GetPea(peaName)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (factory DefaultPeaFactory) isEagerSharedPea(definition PeaDefinition) bool {
+	return definition != nil &&
+		!definition.IsAbstract() &&
+		definition.GetScope() == SharedScope &&
+		!definition.IsLazyInit() &&
+		!factory.isExcludedType(definition.GetPeaType()) &&
+		factory.isActivePeaDefinition(definition)
+}
+
+func (factory DefaultPeaFactory) DestroySharedPeas() error {
+	var result error
+	peaNames := factory.dependencies.sortByDependencies(factory.GetSharedPeaNames())
+	for index := len(peaNames) - 1; index >= 0; index-- {
+		err := factory.destroySharedPea(peaNames[index])
+		if err != nil && result == nil {
+			result = err
+		}
+	}
+	return result
+}
+
+func (factory DefaultPeaFactory) destroySharedPea(name string) error {
+	sharedPea := factory.GetSharedPea(name)
+	factory.RemoveSharedPea(name)
+	factory.dependencies.removePea(name)
+
+	destroyer, ok := sharedPea.(PeaDestroyer)
+	if ok {
+		err := destroyer.DestroyPea()
+		if err != nil {
+			return NewPeaPreparationError(name, "pea could not be destroyed : "+err.Error())
+		}
+	}
+
+	peaDefinition := factory.getMergedPeaDefinition(name)
+	if sharedPea == nil || peaDefinition == nil || peaDefinition.GetDestroyMethod() == "" {
+		return nil
+	} else if ok && peaDefinition.GetDestroyMethod() == "DestroyPea" {
+		return nil
 	}
+
+	err := invokePeaMethod(context.Background(), sharedPea, peaDefinition.GetDestroyMethod())
+	if err != nil {
+		return NewPeaPreparationError(name, "pea could not be destroyed : "+err.Error())
+	}
+	return nil
 }
 
 func (factory DefaultPeaFactory) isExcludedType(typ goo.Type) bool {
@@ -1,12 +1,24 @@
 package peas
 
 import (
+	"context"
 	"errors"
 	"github.com/procyon-projects/goo"
 	"github.com/stretchr/testify/assert"
+	"net/http"
+	"sync/atomic"
 	"testing"
+	"time"
 )
 
+func creationErrorCause(t *testing.T, err error) error {
+	var creationErr PeaCreationError
+	if !assert.True(t, errors.As(err, &creationErr), "error is not a PeaCreationError : %v", err) {
+		return err
+	}
+	return creationErr.GetCause()
+}
+
 func TestDefaultPeaFactory_GetPeaWithEmptyString(t *testing.T) {
 	peaFactory := NewDefaultPeaFactory()
 	_, err := peaFactory.GetPea("")
@@ -92,6 +104,38 @@ func TestDefaultPeaFactory_GetPeaByNameAndTypeForDefinitionWithFunction(t *testi
 	assert.NotNil(t, pea)
 }
 
+func TestDefaultPeaFactory_GetPeaByTypeForDefinitionWithFunctionReturningError(t *testing.T) {
+	peaFactory := NewDefaultPeaFactory()
+	peaFactory.RegisterPeaDefinition("aPea", NewSimplePeaDefinition(goo.GetType(newStructFunctionWithMoreReturnValuesThanOne)))
+
+	pea, err := peaFactory.GetPeaByType(goo.GetType(testStruct{}))
+	assert.Nil(t, err)
+	assert.IsType(t, testStruct{}, pea)
+
+	peaFactory.RegisterPeaDefinition("failingPea", NewSimplePeaDefinition(goo.GetType(func() (*aStruct, error) {
+		return nil, errors.New("connection refused")
+	})))
+	_, err = peaFactory.GetPea("failingPea")
+	assert.NotNil(t, err)
+	assert.Equal(t, "connection refused", creationErrorCause(t, err).Error())
+}
+
+func TestDefaultPeaFactory_GetPeaWithInvalidArgumentType(t *testing.T) {
+	peaFactory := NewDefaultPeaFactory()
+	peaFactory.RegisterPeaDefinition("aPea", NewSimplePeaDefinition(goo.GetType(newStructFunctionWithParameters),
+		WithArgument(0, 5)))
+	peaFactory.RegisterPeaDefinition("anotherPea", NewSimplePeaDefinition(goo.GetType(newStructFunctionWithParameters),
+		WithArgument(1, nil)))
+
+	_, err := peaFactory.GetPea("aPea")
+	assert.NotNil(t, err)
+	assert.Equal(t, "argument 0 of type int is not assignable to parameter of type string", creationErrorCause(t, err).Error())
+
+	_, err = peaFactory.GetPea("anotherPea")
+	assert.NotNil(t, err)
+	assert.Equal(t, "argument 1 cannot be nil for parameter of type int", creationErrorCause(t, err).Error())
+}
+
 func TestDefaultPeaFactory_GetPeaByNameAndArgs(t *testing.T) {
 	peaFactory := NewDefaultPeaFactory()
 
@@ -192,14 +236,14 @@ func TestDefaultPeaFactory_PeaProcessors(t *testing.T) {
 	testPeaProcessor.errBeforePeaInitialization = peaErr
 	pea, err = peaFactory.GetPea("testPea")
 	assert.NotNil(t, err)
-	assert.Equal(t, "pea error", err.Error())
+	assert.Equal(t, "pea error", creationErrorCause(t, err).Error())
 	//assert.Nil(t, pea)
 
 	testPeaProcessor.errBeforePeaInitialization = nil
 	testPeaProcessor.errAfterPeaInitialization = peaErr
 	pea, err = peaFactory.GetPea("testPea")
 	assert.NotNil(t, err)
-	assert.Equal(t, "pea error", err.Error())
+	assert.Equal(t, "pea error", creationErrorCause(t, err).Error())
 	//assert.Nil(t, pea)
 }
 
@@ -286,3 +330,775 @@ func TestDefaultPeaFactory_PreInstantiateSharedPeas(t *testing.T) {
 
 	peaFactory.PreInstantiateSharedPeas()
 }
+
+type awareStruct struct {
+	peaName       string
+	peaFactory    PeaFactory
+	peaDefinition PeaDefinition
+}
+
+func newAwareStruct() *awareStruct {
+	return &awareStruct{}
+}
+
+func (aware *awareStruct) SetPeaName(name string) {
+	aware.peaName = name
+}
+
+func (aware *awareStruct) SetPeaFactory(factory PeaFactory) {
+	aware.peaFactory = factory
+}
+
+func (aware *awareStruct) SetPeaDefinition(definition PeaDefinition) {
+	aware.peaDefinition = definition
+}
+
+type awareCheckingPeaProcessor struct {
+	awarePeaNames []string
+}
+
+func (processor *awareCheckingPeaProcessor) BeforePeaInitialization(peaName string, pea interface{}) (interface{}, error) {
+	if aware, ok := pea.(*awareStruct); ok && aware.peaName == peaName && aware.peaFactory != nil && aware.peaDefinition != nil {
+		processor.awarePeaNames = append(processor.awarePeaNames, peaName)
+	}
+	return pea, nil
+}
+
+func (processor *awareCheckingPeaProcessor) AfterPeaInitialization(peaName string, pea interface{}) (interface{}, error) {
+	return pea, nil
+}
+
+func TestDefaultPeaFactory_AwareMethodsForSharedPea(t *testing.T) {
+	peaFactory := NewDefaultPeaFactory()
+
+	peaDefinition := NewSimplePeaDefinition(goo.GetType(newAwareStruct))
+	peaFactory.RegisterPeaDefinition("awarePea", peaDefinition)
+	processor := &awareCheckingPeaProcessor{}
+	peaFactory.AddPeaProcessor(processor)
+
+	pea, err := peaFactory.GetPea("awarePea")
+	assert.Nil(t, err)
+
+	awarePea := pea.(*awareStruct)
+	assert.Equal(t, "awarePea", awarePea.peaName)
+	assert.Equal(t, peaDefinition, awarePea.peaDefinition)
+	assert.NotNil(t, awarePea.peaFactory)
+	assert.True(t, awarePea.peaFactory.ContainsPea("awarePea"))
+	assert.Equal(t, []string{"awarePea"}, processor.awarePeaNames)
+}
+
+func TestDefaultPeaFactory_AwareMethodsForPrototypePea(t *testing.T) {
+	peaFactory := NewDefaultPeaFactory()
+
+	peaDefinition := NewSimplePeaDefinition(goo.GetType(awareStruct{}), WithScope(PrototypeScope))
+	peaFactory.RegisterPeaDefinition("awarePea", peaDefinition)
+	processor := &awareCheckingPeaProcessor{}
+	peaFactory.AddPeaProcessor(processor)
+
+	pea1, err := peaFactory.GetPea("awarePea")
+	assert.Nil(t, err)
+	pea2, err := peaFactory.GetPea("awarePea")
+	assert.Nil(t, err)
+
+	for _, pea := range []interface{}{pea1, pea2} {
+		awarePea := pea.(*awareStruct)
+		assert.Equal(t, "awarePea", awarePea.peaName)
+		assert.Equal(t, peaDefinition, awarePea.peaDefinition)
+		assert.NotNil(t, awarePea.peaFactory)
+	}
+	assert.Equal(t, []string{"awarePea", "awarePea"}, processor.awarePeaNames)
+}
+
+type destroyEventsPea struct {
+	name   string
+	events *lifecycleEvents
+}
+
+func (pea destroyEventsPea) DestroyPea() error {
+	pea.events.add("destroy:" + pea.name)
+	if pea.name == "failing" {
+		return errors.New("connection refused")
+	}
+	return nil
+}
+
+type repositoryPea struct {
+	destroyEventsPea
+}
+
+type poolPea struct {
+	destroyEventsPea
+}
+
+func TestDefaultPeaFactory_DestroySharedPeas(t *testing.T) {
+	events := &lifecycleEvents{}
+	peaFactory := NewDefaultPeaFactory()
+	peaFactory.RegisterPeaDefinition("repository", NewSimplePeaDefinition(goo.GetType(func(pool poolPea) repositoryPea {
+		return repositoryPea{destroyEventsPea{"repository", events}}
+	})))
+	peaFactory.RegisterPeaDefinition("pool", NewSimplePeaDefinition(goo.GetType(func() poolPea {
+		return poolPea{destroyEventsPea{"pool", events}}
+	})))
+	peaFactory.RegisterSharedPea("failing", destroyEventsPea{"failing", events})
+
+	err := peaFactory.PreInstantiateSharedPeas()
+	assert.Nil(t, err)
+	assert.Equal(t, 3, peaFactory.GetSharedPeaCount())
+
+	err = peaFactory.DestroySharedPeas()
+	assert.NotNil(t, err)
+	assert.Equal(t, "failing : pea could not be destroyed : connection refused", err.Error())
+	assert.Equal(t, []string{"destroy:repository", "destroy:pool", "destroy:failing"}, events.events)
+	assert.Equal(t, 0, peaFactory.GetSharedPeaCount())
+}
+
+func TestDefaultPeaFactory_PreInstantiateSharedPeasWhenPeaCannotBeCreated(t *testing.T) {
+	peaFactory := NewDefaultPeaFactory()
+	peaFactory.RegisterPeaDefinition("failingPea", NewSimplePeaDefinition(goo.GetType(newAwareStruct)))
+	peaFactory.AddPeaProcessor(&testPeaProcessor{errAfterPeaInitialization: errors.New("pea error")})
+
+	err := peaFactory.PreInstantiateSharedPeas()
+	assert.NotNil(t, err)
+	assert.Regexp(t, `^failingPea : pea defined at .*factory_test\.go:\d+ could not be created : pea error$`, err.Error())
+}
+
+type databasePea struct {
+	ctx         context.Context
+	initialized bool
+}
+
+func (pea *databasePea) InitializePea(ctx context.Context) error {
+	pea.initialized = ctx != nil
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(20 * time.Millisecond):
+	}
+	return nil
+}
+
+func newDatabasePea(ctx context.Context) *databasePea {
+	return &databasePea{ctx: ctx}
+}
+
+func TestDefaultPeaFactory_ContextPeaInitializer(t *testing.T) {
+	peaFactory := NewDefaultPeaFactory()
+	peaFactory.RegisterPeaDefinition("databasePea", NewSimplePeaDefinition(goo.GetType(newDatabasePea)))
+
+	pea, err := peaFactory.GetPea("databasePea")
+	assert.Nil(t, err)
+	assert.NotNil(t, pea.(*databasePea).ctx)
+	assert.True(t, pea.(*databasePea).initialized)
+}
+
+func TestDefaultPeaFactory_InitializationTimeoutForDefinition(t *testing.T) {
+	peaFactory := NewDefaultPeaFactory()
+	peaFactory.RegisterPeaDefinition("databasePea", NewSimplePeaDefinition(goo.GetType(newDatabasePea),
+		WithInitializationTimeout(time.Millisecond)))
+
+	pea, err := peaFactory.GetPea("databasePea")
+	assert.Nil(t, pea)
+	assert.NotNil(t, err)
+	assert.Equal(t, "databasePea : Pea could not be initialized within 1ms", creationErrorCause(t, err).Error())
+	var timeoutErr PeaInitializationTimeoutError
+	assert.True(t, errors.As(err, &timeoutErr))
+	assert.False(t, peaFactory.ContainsPea("databasePea"))
+}
+
+func TestDefaultPeaFactory_DefaultInitializationTimeout(t *testing.T) {
+	peaFactory := NewDefaultPeaFactory(WithDefaultInitializationTimeout(time.Millisecond))
+	peaFactory.RegisterPeaDefinition("databasePea", NewSimplePeaDefinition(goo.GetType(newDatabasePea)))
+
+	_, err := peaFactory.GetPea("databasePea")
+	assert.NotNil(t, err)
+	assert.Equal(t, "databasePea : Pea could not be initialized within 1ms", creationErrorCause(t, err).Error())
+
+	peaFactory.RegisterPeaDefinition("databasePea", NewSimplePeaDefinition(goo.GetType(newDatabasePea),
+		WithInitializationTimeout(time.Second)))
+	pea, err := peaFactory.GetPea("databasePea")
+	assert.Nil(t, err)
+	assert.True(t, pea.(*databasePea).initialized)
+}
+
+func TestDefaultPeaFactory_NoInitializationTimeout(t *testing.T) {
+	peaFactory := NewDefaultPeaFactory(WithDefaultInitializationTimeout(time.Millisecond))
+	peaFactory.RegisterPeaDefinition("databasePea", NewSimplePeaDefinition(goo.GetType(newDatabasePea),
+		WithInitializationTimeout(NoInitializationTimeout)))
+
+	pea, err := peaFactory.GetPea("databasePea")
+	assert.Nil(t, err)
+	assert.True(t, pea.(*databasePea).initialized)
+}
+
+type slowConnectionPea struct {
+}
+
+func newSlowConnectionPea() *slowConnectionPea {
+	time.Sleep(30 * time.Millisecond)
+	return &slowConnectionPea{}
+}
+
+type connectionUserPea struct {
+	connection *slowConnectionPea
+}
+
+func newConnectionUserPea(connection *slowConnectionPea) connectionUserPea {
+	return connectionUserPea{connection}
+}
+
+func TestDefaultPeaFactory_InitializationTimeoutExcludesDependencies(t *testing.T) {
+	peaFactory := NewDefaultPeaFactory()
+	peaFactory.RegisterPeaDefinition("connection", NewSimplePeaDefinition(goo.GetType(newSlowConnectionPea)))
+	peaFactory.RegisterPeaDefinition("connectionUser", NewSimplePeaDefinition(goo.GetType(newConnectionUserPea),
+		WithInitializationTimeout(10*time.Millisecond)))
+
+	pea, err := peaFactory.GetPea("connectionUser")
+	assert.Nil(t, err)
+	assert.NotNil(t, pea.(connectionUserPea).connection)
+}
+
+type blockingInitializerPea struct {
+	initialized *int32
+}
+
+func (pea *blockingInitializerPea) InitializePea(ctx context.Context) error {
+	time.Sleep(30 * time.Millisecond)
+	atomic.AddInt32(pea.initialized, 1)
+	return nil
+}
+
+type countingPeaProcessor struct {
+	count *int32
+}
+
+func (processor countingPeaProcessor) BeforePeaInitialization(peaName string, pea interface{}) (interface{}, error) {
+	return pea, nil
+}
+
+func (processor countingPeaProcessor) AfterPeaInitialization(peaName string, pea interface{}) (interface{}, error) {
+	atomic.AddInt32(processor.count, 1)
+	return pea, nil
+}
+
+func TestDefaultPeaFactory_InitializationTimeoutStopsCreation(t *testing.T) {
+	var initialized, processed int32
+	peaFactory := NewDefaultPeaFactory(WithDefaultInitializationTimeout(5 * time.Millisecond))
+	peaFactory.AddPeaProcessor(countingPeaProcessor{&processed})
+	peaFactory.RegisterPeaDefinition("blockingPea", NewSimplePeaDefinition(goo.GetType(func() *blockingInitializerPea {
+		return &blockingInitializerPea{&initialized}
+	})))
+
+	_, err := peaFactory.GetPea("blockingPea")
+	var timeoutErr PeaInitializationTimeoutError
+	assert.True(t, errors.As(err, &timeoutErr))
+
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&initialized))
+	assert.Equal(t, int32(0), atomic.LoadInt32(&processed))
+	assert.False(t, peaFactory.ContainsSharedPea("blockingPea"))
+}
+
+type panickingInitializerPea struct {
+}
+
+func (pea *panickingInitializerPea) InitializePea(ctx context.Context) error {
+	panic("initializer failed")
+}
+
+func TestDefaultPeaFactory_InitializationTimeoutPropagatesPanic(t *testing.T) {
+	peaFactory := NewDefaultPeaFactory(WithDefaultInitializationTimeout(time.Second))
+	peaFactory.RegisterPeaDefinition("panickingConstructorPea", NewSimplePeaDefinition(goo.GetType(func() *aStruct {
+		panic("constructor failed")
+	})))
+	peaFactory.RegisterPeaDefinition("panickingInitializerPea", NewSimplePeaDefinition(goo.GetType(func() *panickingInitializerPea {
+		return &panickingInitializerPea{}
+	})))
+
+	assert.PanicsWithValue(t, "constructor failed", func() {
+		peaFactory.GetPea("panickingConstructorPea")
+	})
+	assert.PanicsWithValue(t, "initializer failed", func() {
+		peaFactory.GetPea("panickingInitializerPea")
+	})
+}
+
+func TestDefaultPeaFactory_PreInstantiateSharedPeasInRegistrationOrder(t *testing.T) {
+	events := &lifecycleEvents{}
+	peaFactory := NewDefaultPeaFactory()
+	peaFactory.RegisterPeaDefinition("pool", NewSimplePeaDefinition(goo.GetType(func() poolPea {
+		events.add("pool")
+		return poolPea{}
+	})))
+	peaFactory.RegisterPeaDefinition("aPea", NewSimplePeaDefinition(goo.GetType(func() aStruct {
+		events.add("aPea")
+		return aStruct{}
+	})))
+	peaFactory.RegisterPeaDefinition("repository", NewSimplePeaDefinition(goo.GetType(func() repositoryPea {
+		events.add("repository")
+		return repositoryPea{}
+	})))
+
+	err := peaFactory.PreInstantiateSharedPeas()
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"pool", "aPea", "repository"}, events.events)
+	assert.Equal(t, []string{"pool", "aPea", "repository"}, peaFactory.GetSharedPeaNames())
+}
+
+func TestDefaultPeaFactory_WithRegistries(t *testing.T) {
+	sharedPeaRegistry := NewDefaultSharedPeaRegistry()
+	peaDefinitionRegistry := NewDefaultPeaDefinitionRegistry()
+	peaDefinitionRegistry.SetDefinitionOverridingPolicy(DenyDefinitionOverriding)
+	peaFactory := NewDefaultPeaFactory(WithSharedPeaRegistry(sharedPeaRegistry), WithPeaDefinitionRegistry(peaDefinitionRegistry))
+
+	err := peaFactory.RegisterPeaDefinition("aPea", NewSimplePeaDefinition(goo.GetType(newAStruct)))
+	assert.Nil(t, err)
+	err = peaFactory.RegisterPeaDefinition("aPea", NewSimplePeaDefinition(goo.GetType(newAStruct)))
+	assert.IsType(t, PeaDefinitionOverrideError{}, err)
+	assert.Regexp(t, `factory_test\.go:\d+$`, err.(PeaDefinitionOverrideError).GetNewLocation())
+
+	_, err = peaFactory.GetPea("aPea")
+	assert.Nil(t, err)
+	assert.True(t, sharedPeaRegistry.ContainsSharedPea("aPea"))
+}
+
+func TestDefaultPeaFactory_NewChildPeaFactory(t *testing.T) {
+	parentFactory := NewDefaultPeaFactory()
+	parentFactory.RegisterPeaDefinition("aPea", NewSimplePeaDefinition(goo.GetType(newAStruct)))
+
+	childFactory := NewChildPeaFactory(parentFactory)
+	childFactory.RegisterPeaDefinition("bPea", NewSimplePeaDefinition(goo.GetType(newBStruct)))
+	assert.Equal(t, parentFactory, childFactory.GetParentPeaFactory())
+
+	pea, err := childFactory.GetPea("aPea")
+	assert.Nil(t, err)
+	assert.IsType(t, aStruct{}, pea)
+	assert.True(t, parentFactory.ContainsSharedPea("aPea"))
+	assert.False(t, childFactory.ContainsSharedPea("aPea"))
+	assert.True(t, childFactory.ContainsPea("aPea"))
+
+	pea, err = childFactory.GetPeaByType(goo.GetType(aStruct{}))
+	assert.Nil(t, err)
+	assert.IsType(t, aStruct{}, pea)
+	assert.Equal(t, []string{"aPea"}, childFactory.GetPeaNamesByType(goo.GetType(aStruct{})))
+
+	pea, err = childFactory.GetPea("bPea")
+	assert.Nil(t, err)
+	assert.IsType(t, bStruct{}, pea)
+
+	_, err = parentFactory.GetPea("bPea")
+	assert.NotNil(t, err)
+	assert.False(t, parentFactory.ContainsPea("bPea"))
+	assert.Empty(t, parentFactory.GetPeaNamesByType(goo.GetType(bStruct{})))
+}
+
+func TestDefaultPeaFactory_NewChildPeaFactoryShadowsParentDefinitions(t *testing.T) {
+	events := &lifecycleEvents{}
+	parentFactory := NewDefaultPeaFactory()
+	parentFactory.RegisterPeaDefinition("pool", NewSimplePeaDefinition(goo.GetType(func() poolPea {
+		return poolPea{destroyEventsPea{"parentPool", events}}
+	})))
+
+	childFactory := NewChildPeaFactory(parentFactory)
+	childFactory.RegisterPeaDefinition("pool", NewSimplePeaDefinition(goo.GetType(func() poolPea {
+		return poolPea{destroyEventsPea{"childPool", events}}
+	})))
+	childFactory.RegisterPeaDefinition("repository", NewSimplePeaDefinition(goo.GetType(func(pool poolPea) repositoryPea {
+		events.add("inject:" + pool.name)
+		return repositoryPea{destroyEventsPea{"repository", events}}
+	})))
+
+	err := childFactory.PreInstantiateSharedPeas()
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"inject:childPool"}, events.events)
+	assert.False(t, parentFactory.ContainsSharedPea("pool"))
+
+	pea, err := childFactory.GetPea("pool")
+	assert.Nil(t, err)
+	assert.Equal(t, "childPool", pea.(poolPea).name)
+}
+
+func TestDefaultPeaFactory_DestroyChildPeaFactory(t *testing.T) {
+	events := &lifecycleEvents{}
+	parentFactory := NewDefaultPeaFactory()
+	parentFactory.RegisterPeaDefinition("pool", NewSimplePeaDefinition(goo.GetType(func() poolPea {
+		return poolPea{destroyEventsPea{"pool", events}}
+	})))
+
+	childFactory := NewChildPeaFactory(parentFactory, WithParallelPreInstantiation(2))
+	childFactory.RegisterPeaDefinition("repository", NewSimplePeaDefinition(goo.GetType(func(pool poolPea) repositoryPea {
+		return repositoryPea{destroyEventsPea{"repository", events}}
+	})))
+
+	err := childFactory.PreInstantiateSharedPeas()
+	assert.Nil(t, err)
+	assert.True(t, parentFactory.ContainsSharedPea("pool"))
+	assert.Equal(t, []string{"repository"}, childFactory.GetSharedPeaNames())
+
+	err = childFactory.DestroySharedPeas()
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"destroy:repository"}, events.events)
+	assert.True(t, parentFactory.ContainsSharedPea("pool"))
+}
+
+type handlerPea struct {
+	handler   http.HandlerFunc
+	allowlist []string
+	timeout   time.Duration
+	service   testInterface
+}
+
+func TestDefaultPeaFactory_CreatePeaWithNonStructDependencies(t *testing.T) {
+	peaFactory := NewDefaultPeaFactory()
+	peaFactory.RegisterSharedPea("handler", http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	peaFactory.RegisterSharedPea("allowlist", []string{"localhost"})
+	peaFactory.RegisterPeaDefinition("timeout", NewSimplePeaDefinition(goo.GetType(func() time.Duration {
+		return 5 * time.Second
+	})))
+	peaFactory.RegisterPeaDefinition("service", NewSimplePeaDefinition(goo.GetType(newTestInterface)))
+	peaFactory.RegisterPeaDefinition("handlerPea", NewSimplePeaDefinition(goo.GetType(func(handler http.HandlerFunc,
+		allowlist []string,
+		timeout time.Duration,
+		service testInterface) handlerPea {
+		return handlerPea{handler, allowlist, timeout, service}
+	})))
+
+	pea, err := peaFactory.GetPea("handlerPea")
+	assert.Nil(t, err)
+	assert.NotNil(t, pea.(handlerPea).handler)
+	assert.Equal(t, []string{"localhost"}, pea.(handlerPea).allowlist)
+	assert.Equal(t, 5*time.Second, pea.(handlerPea).timeout)
+	assert.Equal(t, testStruct{}, pea.(handlerPea).service)
+
+	pea, err = peaFactory.GetPeaByType(goo.GetType(time.Duration(0)))
+	assert.Nil(t, err)
+	assert.Equal(t, 5*time.Second, pea)
+}
+
+func TestDefaultPeaFactory_CreatePeaWithPredeclaredTypeParameters(t *testing.T) {
+	peaFactory := NewDefaultPeaFactory()
+	peaFactory.RegisterSharedPea("host", "localhost")
+	peaFactory.RegisterSharedPea("scheme", "https")
+	peaFactory.RegisterSharedPea("port", 8080)
+
+	arguments := make([]interface{}, 0)
+	constructor := func(host string, port int) aStruct {
+		arguments = append(arguments, host, port)
+		return aStruct{}
+	}
+	peaFactory.RegisterPeaDefinition("aPea", NewSimplePeaDefinition(goo.GetType(constructor)))
+	peaFactory.RegisterPeaDefinition("anotherPea", NewSimplePeaDefinition(goo.GetType(constructor),
+		WithArgument(0, "localhost"), WithArgument(1, 8080)))
+
+	_, err := peaFactory.GetPea("aPea")
+	assert.Nil(t, err)
+	_, err = peaFactory.GetPea("anotherPea")
+	assert.Nil(t, err)
+	assert.Equal(t, []interface{}{"", 0, "localhost", 8080}, arguments)
+}
+
+func TestDefaultPeaFactory_GetPeaForPrototypeCallsConstructor(t *testing.T) {
+	peaFactory := NewDefaultPeaFactory()
+	count := 0
+	peaFactory.RegisterPeaDefinition("prototypePea", NewSimplePeaDefinition(goo.GetType(func() aStruct {
+		count++
+		return aStruct{}
+	}), WithScope(PrototypeScope)))
+
+	peaFactory.GetPea("prototypePea")
+	peaFactory.GetPea("prototypePea")
+	assert.Equal(t, 2, count)
+}
+
+func TestDefaultPeaFactory_CreatePeaWithExposedTypes(t *testing.T) {
+	peaFactory := NewDefaultPeaFactory()
+	testInterfaceType := goo.GetType((*testInterface)(nil))
+	peaFactory.RegisterPeaDefinition("store", NewSimplePeaDefinition(goo.GetType(func() *postgresStore {
+		return &postgresStore{}
+	}), WithExposedTypes(testInterfaceType)))
+	peaFactory.RegisterSharedPeaAs("sharedStore", &postgresStore{}, goo.GetType((*PeaInitializer)(nil)))
+
+	assert.Equal(t, []string{"store"}, peaFactory.GetPeaNamesByType(testInterfaceType))
+	assert.Empty(t, peaFactory.GetPeaNamesByType(goo.GetType(postgresStore{})))
+
+	pea, err := peaFactory.GetPeaByType(testInterfaceType)
+	assert.Nil(t, err)
+	assert.IsType(t, &postgresStore{}, pea)
+	assert.Equal(t, []string{"store"}, peaFactory.GetSharedPeaNamesByType(testInterfaceType))
+
+	_, err = peaFactory.GetPeaByNameAndType("store", goo.GetType(postgresStore{}))
+	assert.NotNil(t, err)
+
+	_, err = peaFactory.GetPeaByNameAndType("sharedStore", testInterfaceType)
+	assert.NotNil(t, err)
+	pea, err = peaFactory.GetPeaByNameAndType("sharedStore", goo.GetType((*PeaInitializer)(nil)))
+	assert.Nil(t, err)
+	assert.NotNil(t, pea)
+}
+
+func TestDefaultPeaFactory_PreInstantiateSharedPeasWithLazyInit(t *testing.T) {
+	for _, workerCount := range []int{0, 2} {
+		peaFactory := NewDefaultPeaFactory(WithParallelPreInstantiation(workerCount))
+		peaFactory.RegisterPeaDefinition("lazyPea", NewSimplePeaDefinition(goo.GetType(newStructFunction), WithLazyInit()))
+		peaFactory.RegisterPeaDefinition("aPea", NewSimplePeaDefinition(goo.GetType(newAStruct), WithLazyInit()))
+		peaFactory.RegisterPeaDefinition("bPea", NewSimplePeaDefinition(goo.GetType(newBStruct)))
+
+		err := peaFactory.PreInstantiateSharedPeas()
+		assert.Nil(t, err)
+		assert.False(t, peaFactory.ContainsSharedPea("lazyPea"))
+		assert.True(t, peaFactory.ContainsSharedPea("aPea"))
+		assert.True(t, peaFactory.ContainsSharedPea("bPea"))
+
+		pea, err := peaFactory.GetPea("lazyPea")
+		assert.Nil(t, err)
+		assert.NotNil(t, pea)
+		assert.True(t, peaFactory.ContainsSharedPea("lazyPea"))
+	}
+}
+
+func TestDefaultPeaFactory_GetLazyPeaConcurrently(t *testing.T) {
+	peaFactory := NewDefaultPeaFactory()
+	var creationCount int32
+	peaFactory.RegisterPeaDefinition("lazyPea", NewSimplePeaDefinition(goo.GetType(func() *aStruct {
+		atomic.AddInt32(&creationCount, 1)
+		time.Sleep(20 * time.Millisecond)
+		return &aStruct{}
+	}), WithLazyInit()))
+
+	peas := make(chan interface{}, 4)
+	errs := make(chan error, 4)
+	for index := 0; index < 4; index++ {
+		go func() {
+			pea, err := peaFactory.GetPea("lazyPea")
+			peas <- pea
+			errs <- err
+		}()
+	}
+
+	first := <-peas
+	for index := 0; index < 4; index++ {
+		assert.Nil(t, <-errs)
+	}
+	for index := 1; index < 4; index++ {
+		assert.Same(t, first, <-peas)
+	}
+	assert.Equal(t, int32(1), atomic.LoadInt32(&creationCount))
+}
+
+func TestDefaultPeaFactory_AutowireCandidate(t *testing.T) {
+	peaFactory := NewDefaultPeaFactory()
+	peaFactory.RegisterPeaDefinition("aPea", NewSimplePeaDefinition(goo.GetType(newAStruct)))
+	peaFactory.RegisterPeaDefinition("anotherAPea", NewSimplePeaDefinition(goo.GetType(newAStruct), WithAutowireCandidate(false)))
+	peaFactory.RegisterPeaDefinition("bPea", NewSimplePeaDefinition(goo.GetType(newBStruct)))
+
+	pea, err := peaFactory.GetPea("bPea")
+	assert.Nil(t, err)
+	assert.NotNil(t, pea)
+	assert.Equal(t, []string{"aPea"}, peaFactory.dependencies.getDependencies("bPea"))
+
+	pea, err = peaFactory.GetPeaByType(goo.GetType(aStruct{}))
+	assert.Nil(t, err)
+	assert.NotNil(t, pea)
+
+	pea, err = peaFactory.GetPea("anotherAPea")
+	assert.Nil(t, err)
+	assert.NotNil(t, pea)
+	assert.Equal(t, []string{"aPea", "anotherAPea"}, peaFactory.GetPeaNamesByType(goo.GetType(aStruct{})))
+
+	peaFactory.RemovePeaDefinition("aPea")
+	_, err = peaFactory.GetPeaByType(goo.GetType(aStruct{}))
+	assert.NotNil(t, err)
+}
+
+func TestDefaultPeaFactory_AutowireCandidateAfterPreInstantiation(t *testing.T) {
+	peaFactory := NewDefaultPeaFactory()
+	peaFactory.RegisterPeaDefinition("aPea", NewSimplePeaDefinition(goo.GetType(newAStruct)))
+	peaFactory.RegisterPeaDefinition("anotherAPea", NewSimplePeaDefinition(goo.GetType(newAStruct), WithAutowireCandidate(false)))
+	peaFactory.RegisterPeaDefinition("bPea", NewSimplePeaDefinition(goo.GetType(newBStruct), WithScope(PrototypeScope)))
+
+	err := peaFactory.PreInstantiateSharedPeas()
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"aPea", "anotherAPea"}, peaFactory.GetSharedPeaNames())
+
+	pea, err := peaFactory.GetPea("bPea")
+	assert.Nil(t, err)
+	assert.NotNil(t, pea)
+	assert.Equal(t, []string{"aPea"}, peaFactory.dependencies.getDependencies("bPea"))
+}
+
+func TestDefaultPeaFactory_DependsOn(t *testing.T) {
+	for _, workerCount := range []int{0, 2} {
+		events := &lifecycleEvents{}
+		peaFactory := NewDefaultPeaFactory(WithParallelPreInstantiation(workerCount))
+		peaFactory.RegisterPeaDefinition("repository", NewSimplePeaDefinition(goo.GetType(func() repositoryPea {
+			events.add("create:repository")
+			return repositoryPea{destroyEventsPea{"repository", events}}
+		}), WithDependsOn("migrator")))
+		peaFactory.RegisterPeaDefinition("migrator", NewSimplePeaDefinition(goo.GetType(func() destroyEventsPea {
+			events.add("create:migrator")
+			return destroyEventsPea{"migrator", events}
+		})))
+
+		err := peaFactory.PreInstantiateSharedPeas()
+		assert.Nil(t, err)
+
+		err = peaFactory.DestroySharedPeas()
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"create:migrator", "create:repository", "destroy:repository", "destroy:migrator"}, events.events)
+	}
+}
+
+func TestDefaultPeaFactory_DependsOnWithCycle(t *testing.T) {
+	for _, scope := range []PeaScope{SharedScope, PrototypeScope} {
+		peaFactory := NewDefaultPeaFactory()
+		peaFactory.RegisterPeaDefinition("aPea", NewSimplePeaDefinition(goo.GetType(newAStruct), WithScope(scope), WithDependsOn("bPea")))
+		peaFactory.RegisterPeaDefinition("bPea", NewSimplePeaDefinition(goo.GetType(newAStruct), WithScope(scope), WithDependsOn("aPea")))
+
+		_, err := peaFactory.GetPea("aPea")
+		assert.NotNil(t, err)
+		assert.Equal(t, "aPea : circular dependency cycle : aPea -> bPea -> aPea", err.Error())
+	}
+
+	peaFactory := NewDefaultPeaFactory(WithParallelPreInstantiation(2))
+	peaFactory.RegisterPeaDefinition("aPea", NewSimplePeaDefinition(goo.GetType(newAStruct), WithDependsOn("bPea")))
+	peaFactory.RegisterPeaDefinition("bPea", NewSimplePeaDefinition(goo.GetType(newAStruct), WithDependsOn("aPea")))
+
+	err := peaFactory.PreInstantiateSharedPeas()
+	assert.NotNil(t, err)
+	assert.Equal(t, "aPea : circular dependency cycle : aPea -> bPea -> aPea", err.Error())
+}
+
+func TestDefaultPeaFactory_DependsOnMissingPea(t *testing.T) {
+	peaFactory := NewDefaultPeaFactory()
+	peaFactory.RegisterPeaDefinition("aPea", NewSimplePeaDefinition(goo.GetType(newAStruct), WithDependsOn("migrator")))
+
+	_, err := peaFactory.GetPea("aPea")
+	assert.NotNil(t, err)
+	assert.Equal(t, "aPea : depends-on pea 'migrator' could not be created : pea definition couldn't be found : migrator", err.Error())
+	assert.False(t, peaFactory.ContainsSharedPea("aPea"))
+}
+
+type connectionPea struct {
+	events *lifecycleEvents
+}
+
+func (pea *connectionPea) Open() {
+	pea.events.add("open")
+}
+
+func (pea *connectionPea) Shutdown(ctx context.Context) error {
+	pea.events.add("shutdown")
+	if ctx == nil {
+		return errors.New("context is missing")
+	}
+	return errors.New("connection is already closed")
+}
+
+func (pea *connectionPea) Connect(address string) error {
+	return nil
+}
+
+func TestDefaultPeaFactory_InitAndDestroyMethods(t *testing.T) {
+	events := &lifecycleEvents{}
+	peaFactory := NewDefaultPeaFactory()
+	peaFactory.RegisterPeaDefinition("connection", NewSimplePeaDefinition(goo.GetType(func() *connectionPea {
+		return &connectionPea{events}
+	}), WithInitMethod("Open"), WithDestroyMethod("Shutdown")))
+
+	pea, err := peaFactory.GetPea("connection")
+	assert.Nil(t, err)
+	assert.NotNil(t, pea)
+	assert.Equal(t, []string{"open"}, events.events)
+
+	err = peaFactory.DestroySharedPeas()
+	assert.NotNil(t, err)
+	assert.Equal(t, "connection : pea could not be destroyed : connection is already closed", err.Error())
+	assert.Equal(t, []string{"open", "shutdown"}, events.events)
+}
+
+func TestDefaultPeaFactory_InitMethodWhichCannotBeInvoked(t *testing.T) {
+	events := &lifecycleEvents{}
+	peaFactory := NewDefaultPeaFactory()
+	peaFactory.RegisterPeaDefinition("missingMethod", NewSimplePeaDefinition(goo.GetType(func() *connectionPea {
+		return &connectionPea{events}
+	}), WithInitMethod("Close")))
+	peaFactory.RegisterPeaDefinition("methodWithParameters", NewSimplePeaDefinition(goo.GetType(func() *connectionPea {
+		return &connectionPea{events}
+	}), WithInitMethod("Connect")))
+
+	_, err := peaFactory.GetPea("missingMethod")
+	assert.NotNil(t, err)
+	assert.Equal(t, "method 'Close' could not be found", creationErrorCause(t, err).Error())
+
+	_, err = peaFactory.GetPea("methodWithParameters")
+	assert.NotNil(t, err)
+	assert.Equal(t, "method 'Connect' must not have any parameter except context", creationErrorCause(t, err).Error())
+}
+
+func TestDefaultPeaFactory_ErrorsContainDefinitionSource(t *testing.T) {
+	peaFactory := NewDefaultPeaFactory()
+	peaFactory.RegisterPeaDefinition("aPea", NewSimplePeaDefinition(goo.GetType(newAStruct), WithSource("a.go:1")))
+	peaFactory.RegisterPeaDefinition("anotherAPea", NewSimplePeaDefinition(goo.GetType(newAStruct), WithSource("a.go:2")))
+	peaFactory.RegisterPeaDefinition("bPea", NewSimplePeaDefinition(goo.GetType(newBStruct)))
+
+	_, err := peaFactory.GetPeaByType(goo.GetType(aStruct{}))
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "(candidates : aPea defined at a.go:1, anotherAPea defined at a.go:2)")
+
+	_, err = peaFactory.GetPea("bPea")
+	assert.NotNil(t, err)
+	assert.Equal(t, "dependency of type 'github.com.procyon.projects.procyon.peas.aStruct' cannot be distinguished : bPea "+
+		"(candidates : aPea defined at a.go:1, anotherAPea defined at a.go:2)", creationErrorCause(t, err).Error())
+
+	peaFactory.PreInstantiateSharedPeas()
+	assert.PanicsWithValue(t, "Instances of required type cannot be distinguished : github.com.procyon.projects.procyon.peas.aStruct "+
+		"(candidates : aPea defined at a.go:1, anotherAPea defined at a.go:2)", func() {
+		peaFactory.GetSharedPeaType(goo.GetType(aStruct{}))
+	})
+}
+
+func TestDefaultPeaFactory_PrimaryPea(t *testing.T) {
+	peaFactory := NewDefaultPeaFactory()
+	Define(newAStruct).Named("aPea").Register(peaFactory)
+	Define(func() aStruct {
+		return aStruct{}
+	}).Named("primaryAPea").Primary().Register(peaFactory)
+	Define(newBStruct).Named("bPea").Register(peaFactory)
+
+	pea, err := peaFactory.GetPeaByType(goo.GetType(aStruct{}))
+	assert.Nil(t, err)
+	assert.Equal(t, aStruct{}, pea)
+
+	_, err = peaFactory.GetPea("bPea")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"primaryAPea"}, peaFactory.dependencies.getDependencies("bPea"))
+}
+
+func TestDefaultPeaFactory_MoreThanOnePrimaryPea(t *testing.T) {
+	peaFactory := NewDefaultPeaFactory()
+	peaFactory.RegisterPeaDefinition("aPea", NewSimplePeaDefinition(goo.GetType(newAStruct), WithPrimary(), WithSource("a.go:1")))
+	peaFactory.RegisterPeaDefinition("anotherAPea", NewSimplePeaDefinition(goo.GetType(newAStruct), WithPrimary(), WithSource("a.go:2")))
+	peaFactory.RegisterPeaDefinition("bPea", NewSimplePeaDefinition(goo.GetType(newBStruct)))
+
+	_, err := peaFactory.GetPeaByType(goo.GetType(aStruct{}))
+	assert.Equal(t, "there is more than one primary pea among the candidates : aPea defined at a.go:1, anotherAPea defined at a.go:2", err.Error())
+
+	_, err = peaFactory.GetPea("bPea")
+	assert.NotNil(t, err)
+	assert.Equal(t, "there is more than one primary pea among the candidates : aPea defined at a.go:1, anotherAPea defined at a.go:2", creationErrorCause(t, err).Error())
+}
+
+func TestDefaultPeaFactory_PrimaryPeaInParentFactory(t *testing.T) {
+	parentFactory := NewDefaultPeaFactory()
+	parentFactory.RegisterPeaDefinition("aPea", NewSimplePeaDefinition(goo.GetType(newAStruct)))
+	parentFactory.RegisterPeaDefinition("primaryAPea", NewSimplePeaDefinition(goo.GetType(newAStruct), WithPrimary()))
+
+	peaFactory := NewChildPeaFactory(parentFactory)
+	peaFactory.RegisterPeaDefinition("bPea", NewSimplePeaDefinition(goo.GetType(newBStruct)))
+
+	_, err := peaFactory.GetPeaByType(goo.GetType(aStruct{}))
+	assert.Nil(t, err)
+
+	_, err = peaFactory.GetPea("bPea")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"primaryAPea"}, peaFactory.dependencies.getDependencies("bPea"))
+}
@@ -0,0 +1,88 @@
+package peas
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+const generatedNameSeparator = "#"
+
+type DefaultPeaNameGenerator struct {
+}
+
+func NewDefaultPeaNameGenerator() DefaultPeaNameGenerator {
+	return DefaultPeaNameGenerator{}
+}
+
+func (generator DefaultPeaNameGenerator) GenerateName(peaDefinition PeaDefinition) string {
+	typeName := peaDefinition.GetTypeName()
+	if index := strings.LastIndex(typeName, "."); index != -1 {
+		typeName = typeName[index+1:]
+	}
+	return toLowerCamelCase(typeName)
+}
+
+func (generator DefaultPeaNameGenerator) GenerateUniqueName(peaDefinition PeaDefinition, registry PeaDefinitionRegistry) string {
+	return generateUniqueName(generator.GenerateName(peaDefinition), registry)
+}
+
+type PackageQualifiedPeaNameGenerator struct {
+}
+
+func NewPackageQualifiedPeaNameGenerator() PackageQualifiedPeaNameGenerator {
+	return PackageQualifiedPeaNameGenerator{}
+}
+
+func (generator PackageQualifiedPeaNameGenerator) GenerateName(peaDefinition PeaDefinition) string {
+	peaType := peaDefinition.GetPeaType()
+	if peaType == nil {
+		return ""
+	}
+
+	if peaType.IsFunction() {
+		var ok bool
+		if peaType, ok = getConstructorReturnType(peaType.ToFunctionType()); !ok {
+			return ""
+		}
+	}
+
+	name := toLowerCamelCase(peaType.GetName())
+	if peaType.GetPackageFullName() != "" {
+		name = peaType.GetPackageFullName() + "." + name
+	}
+	return name
+}
+
+func (generator PackageQualifiedPeaNameGenerator) GenerateUniqueName(peaDefinition PeaDefinition, registry PeaDefinitionRegistry) string {
+	return generateUniqueName(generator.GenerateName(peaDefinition), registry)
+}
+
+func generateUniqueName(name string, registry PeaDefinitionRegistry) string {
+	if name == "" || registry == nil {
+		return name
+	}
+
+	uniqueName := name
+	for counter := 1; registry.ContainsPeaDefinition(uniqueName); counter++ {
+		uniqueName = name + generatedNameSeparator + strconv.Itoa(counter)
+	}
+	return uniqueName
+}
+
+func toLowerCamelCase(name string) string {
+	runes := []rune(name)
+	upperCount := 0
+	for upperCount < len(runes) && unicode.IsUpper(runes[upperCount]) {
+		upperCount++
+	}
+
+	if upperCount > 1 && upperCount < len(runes) {
+		upperCount--
+	}
+
+	for index := 0; index < upperCount; index++ {
+		runes[index] = unicode.ToLower(runes[index])
+	}
+	return string(runes)
+}
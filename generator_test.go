@@ -0,0 +1,110 @@
+package peas
+
+import (
+	"github.com/procyon-projects/goo"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+type HTTPClient struct {
+}
+
+func newHTTPClient() *HTTPClient {
+	return &HTTPClient{}
+}
+
+func TestToLowerCamelCase(t *testing.T) {
+	assert.Equal(t, "", toLowerCamelCase(""))
+	assert.Equal(t, "userService", toLowerCamelCase("UserService"))
+	assert.Equal(t, "userService", toLowerCamelCase("userService"))
+	assert.Equal(t, "httpClient", toLowerCamelCase("HTTPClient"))
+	assert.Equal(t, "url", toLowerCamelCase("URL"))
+	assert.Equal(t, "a", toLowerCamelCase("A"))
+}
+
+func TestDefaultPeaNameGenerator_GenerateName(t *testing.T) {
+	generator := NewDefaultPeaNameGenerator()
+	assert.Equal(t, "httpClient", generator.GenerateName(NewSimplePeaDefinition(goo.GetType(newHTTPClient))))
+}
+
+func TestDefaultPeaNameGenerator_GenerateUniqueName(t *testing.T) {
+	peaDefinitionRegistry := NewDefaultPeaDefinitionRegistry()
+	generator := NewDefaultPeaNameGenerator()
+
+	assert.Equal(t, "testStruct", generator.GenerateUniqueName(NewSimplePeaDefinition(goo.GetType(testStruct{})), peaDefinitionRegistry))
+	assert.Equal(t, "testStruct", generator.GenerateUniqueName(NewSimplePeaDefinition(goo.GetType(newStructFunction)), peaDefinitionRegistry))
+	assert.Equal(t, "httpClient", generator.GenerateUniqueName(NewSimplePeaDefinition(goo.GetType(newHTTPClient)), peaDefinitionRegistry))
+
+	peaDefinitionRegistry.RegisterPeaDefinition("httpClient", NewSimplePeaDefinition(goo.GetType(newHTTPClient)))
+	peaDefinitionRegistry.RegisterPeaDefinition("httpClient#1", NewSimplePeaDefinition(goo.GetType(newHTTPClient)))
+	assert.Equal(t, "httpClient#2", generator.GenerateUniqueName(NewSimplePeaDefinition(goo.GetType(newHTTPClient)), peaDefinitionRegistry))
+}
+
+func TestPackageQualifiedPeaNameGenerator_GenerateName(t *testing.T) {
+	generator := NewPackageQualifiedPeaNameGenerator()
+	assert.Equal(t, "github.com.procyon.projects.procyon.peas.httpClient",
+		generator.GenerateName(NewSimplePeaDefinition(goo.GetType(newHTTPClient))))
+}
+
+func TestPackageQualifiedPeaNameGenerator_GenerateUniqueName(t *testing.T) {
+	peaDefinitionRegistry := NewDefaultPeaDefinitionRegistry()
+	generator := NewPackageQualifiedPeaNameGenerator()
+
+	assert.Equal(t, "github.com.procyon.projects.procyon.peas.testStruct",
+		generator.GenerateUniqueName(NewSimplePeaDefinition(goo.GetType(testStruct{})), peaDefinitionRegistry))
+	assert.Equal(t, "github.com.procyon.projects.procyon.peas.httpClient",
+		generator.GenerateUniqueName(NewSimplePeaDefinition(goo.GetType(newHTTPClient)), peaDefinitionRegistry))
+	assert.Equal(t, "", generator.GenerateUniqueName(NewSimplePeaDefinition(goo.GetType(newStructFunctionWithTwoValues)), peaDefinitionRegistry))
+
+	peaDefinitionRegistry.RegisterPeaDefinition("github.com.procyon.projects.procyon.peas.httpClient", NewSimplePeaDefinition(goo.GetType(newHTTPClient)))
+	assert.Equal(t, "github.com.procyon.projects.procyon.peas.httpClient#1",
+		generator.GenerateUniqueName(NewSimplePeaDefinition(goo.GetType(newHTTPClient)), peaDefinitionRegistry))
+}
+
+func TestDefaultPeaDefinitionRegistry_RegisterPeaDefinitionWithGeneratedName(t *testing.T) {
+	peaDefinitionRegistry := NewDefaultPeaDefinitionRegistry()
+
+	peaName, err := peaDefinitionRegistry.RegisterPeaDefinitionWithGeneratedName(NewSimplePeaDefinition(goo.GetType(newHTTPClient)))
+	assert.Nil(t, err)
+	assert.Equal(t, "httpClient", peaName)
+
+	peaName, err = peaDefinitionRegistry.RegisterPeaDefinitionWithGeneratedName(NewSimplePeaDefinition(goo.GetType(newHTTPClient)))
+	assert.Nil(t, err)
+	assert.Equal(t, "httpClient#1", peaName)
+	assert.Equal(t, []string{"httpClient", "httpClient#1"}, peaDefinitionRegistry.GetPeaDefinitionNames())
+
+	_, err = peaDefinitionRegistry.RegisterPeaDefinitionWithGeneratedName(nil)
+	assert.NotNil(t, err)
+	assert.Equal(t, "pea definition must not be nil", err.Error())
+
+	peaDefinitionRegistry.SetPeaNameGenerator(NewPackageQualifiedPeaNameGenerator())
+	_, err = peaDefinitionRegistry.RegisterPeaDefinitionWithGeneratedName(NewSimplePeaDefinition(goo.GetType(newStructFunctionWithTwoValues)))
+	assert.NotNil(t, err)
+	assert.Equal(t, "pea name could not be generated for pea definition : newStructFunctionWithTwoValues", err.Error())
+
+	peaName, err = peaDefinitionRegistry.RegisterPeaDefinitionWithGeneratedName(NewSimplePeaDefinition(goo.GetType(newHTTPClient)))
+	assert.Nil(t, err)
+	assert.Equal(t, "github.com.procyon.projects.procyon.peas.httpClient", peaName)
+}
+
+type fixedPeaNameGenerator struct {
+	name string
+}
+
+func (generator fixedPeaNameGenerator) GenerateName(peaDefinition PeaDefinition) string {
+	return generator.name
+}
+
+func TestDefaultPeaDefinitionRegistry_RegisterPeaDefinitionWithCustomNameGenerator(t *testing.T) {
+	peaDefinitionRegistry := NewDefaultPeaDefinitionRegistry()
+	peaDefinitionRegistry.SetPeaNameGenerator(fixedPeaNameGenerator{"client"})
+
+	peaName, err := peaDefinitionRegistry.RegisterPeaDefinitionWithGeneratedName(NewSimplePeaDefinition(goo.GetType(newHTTPClient)))
+	assert.Nil(t, err)
+	assert.Equal(t, "client", peaName)
+
+	peaName, err = peaDefinitionRegistry.RegisterPeaDefinitionWithGeneratedName(NewSimplePeaDefinition(goo.GetType(newHTTPClient)))
+	assert.Nil(t, err)
+	assert.Equal(t, "client", peaName)
+	assert.Equal(t, []string{"client"}, peaDefinitionRegistry.GetPeaDefinitionNames())
+}
@@ -0,0 +1,199 @@
+package peas
+
+import (
+	"fmt"
+	"strings"
+)
+
+type peaInstantiationGraph struct {
+	peaNames     []string
+	dependencies map[string][]string
+	dependents   map[string][]string
+	instantiate  map[string]bool
+}
+
+func (factory DefaultPeaFactory) newPeaInstantiationGraph() *peaInstantiationGraph {
+	graph := &peaInstantiationGraph{
+		peaNames:     factory.GetPeaDefinitionNames(),
+		dependencies: make(map[string][]string, 0),
+		dependents:   make(map[string][]string, 0),
+		instantiate:  make(map[string]bool, 0),
+	}
+
+	for _, peaName := range graph.peaNames {
+		for _, dependencyName := range factory.getDefinitionDependencyNames(factory.getMergedPeaDefinition(peaName)) {
+			if dependencyName == peaName || containsString(graph.dependencies[peaName], dependencyName) {
+				continue
+			}
+			graph.dependencies[peaName] = append(graph.dependencies[peaName], dependencyName)
+			graph.dependents[dependencyName] = append(graph.dependents[dependencyName], peaName)
+		}
+	}
+
+	marked := make(map[string]bool, 0)
+	var markForInstantiation func(peaName string)
+	markForInstantiation = func(peaName string) {
+		if marked[peaName] {
+			return
+		}
+		marked[peaName] = true
+		peaDefinition := factory.getMergedPeaDefinition(peaName)
+		if peaDefinition != nil && peaDefinition.GetScope() == SharedScope && factory.isActivePeaDefinition(peaDefinition) {
+			graph.instantiate[peaName] = true
+		}
+		for _, dependencyName := range graph.dependencies[peaName] {
+			markForInstantiation(dependencyName)
+		}
+	}
+
+	for _, peaName := range graph.peaNames {
+		peaDefinition := factory.getMergedPeaDefinition(peaName)
+		if !factory.isEagerSharedPea(peaDefinition) {
+			continue
+		}
+		markForInstantiation(peaName)
+	}
+
+	return graph
+}
+
+func (factory DefaultPeaFactory) getDefinitionDependencyNames(definition PeaDefinition) []string {
+	dependencyNames := make([]string, 0)
+	if definition == nil {
+		return dependencyNames
+	}
+
+	for _, dependencyName := range definition.GetDependsOn() {
+		dependencyName = factory.CanonicalName(dependencyName)
+		if factory.PeaDefinitionRegistry.ContainsPeaDefinition(dependencyName) {
+			dependencyNames = append(dependencyNames, dependencyName)
+		}
+	}
+
+	if definition.GetPeaType() == nil || !definition.GetPeaType().IsFunction() {
+		return dependencyNames
+	}
+
+	for parameterIndex, parameterType := range definition.GetPeaType().ToFunctionType().GetFunctionParameterTypes() {
+		if _, ok := definition.GetArguments()[parameterIndex]; ok || parameterType.GetGoType() == contextType ||
+			isPredeclaredType(parameterType) {
+			continue
+		}
+
+		dependencyNames = append(dependencyNames, factory.getLocalAutowireCandidateNames(parameterType)...)
+	}
+	return dependencyNames
+}
+
+func (graph *peaInstantiationGraph) findCycle() []string {
+	const (
+		visiting = 1
+		visited  = 2
+	)
+
+	states := make(map[string]int, len(graph.peaNames))
+	path := make([]string, 0)
+
+	var visit func(peaName string) []string
+	visit = func(peaName string) []string {
+		switch states[peaName] {
+		case visiting:
+			for index, name := range path {
+				if name == peaName {
+					return append(append(make([]string, 0), path[index:]...), peaName)
+				}
+			}
+		case visited:
+			return nil
+		}
+
+		states[peaName] = visiting
+		path = append(path, peaName)
+		for _, dependencyName := range graph.dependencies[peaName] {
+			if cycle := visit(dependencyName); cycle != nil {
+				return cycle
+			}
+		}
+		path = path[:len(path)-1]
+		states[peaName] = visited
+		return nil
+	}
+
+	for _, peaName := range graph.peaNames {
+		if cycle := visit(peaName); cycle != nil {
+			return cycle
+		}
+	}
+	return nil
+}
+
+func (factory DefaultPeaFactory) preInstantiateSharedPeasInParallel() error {
+	graph := factory.newPeaInstantiationGraph()
+	if cycle := graph.findCycle(); cycle != nil {
+		return NewPeaPreparationError(cycle[0], "circular dependency cycle : "+strings.Join(cycle, " -> "))
+	}
+
+	type instantiationResult struct {
+		peaName string
+		err     error
+	}
+
+	tasks := make(chan string, len(graph.peaNames))
+	results := make(chan instantiationResult, len(graph.peaNames))
+	defer close(tasks)
+
+	for worker := 0; worker < factory.preInstantiationWorkers; worker++ {
+		go func() {
+			for peaName := range tasks {
+				results <- instantiationResult{peaName, factory.instantiateSharedPea(peaName, graph.instantiate[peaName])}
+			}
+		}()
+	}
+
+	inProgress := 0
+	remainingDependencies := make(map[string]int, len(graph.peaNames))
+	for _, peaName := range graph.peaNames {
+		remainingDependencies[peaName] = len(graph.dependencies[peaName])
+		if remainingDependencies[peaName] == 0 {
+			tasks <- peaName
+			inProgress++
+		}
+	}
+
+	var result error
+	for ; inProgress > 0; inProgress-- {
+		instantiation := <-results
+		if instantiation.err != nil && result == nil {
+			result = instantiation.err
+		}
+
+		if result != nil {
+			continue
+		}
+
+		for _, dependentName := range graph.dependents[instantiation.peaName] {
+			remainingDependencies[dependentName]--
+			if remainingDependencies[dependentName] == 0 {
+				tasks <- dependentName
+				inProgress++
+			}
+		}
+	}
+
+	return result
+}
+
+func (factory DefaultPeaFactory) instantiateSharedPea(peaName string, instantiate bool) (err error) {
+	if !instantiate {
+		return nil
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			err = NewPeaPreparationError(peaName, fmt.Sprint(r))
+		}
+	}()
+
+	_, err = factory.GetPea(peaName)
+	return
+}
@@ -0,0 +1,167 @@
+package peas
+
+import (
+	"errors"
+	"github.com/procyon-projects/goo"
+	"github.com/stretchr/testify/assert"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type connectionPool struct {
+	warmedUp bool
+}
+
+type cacheWarmer struct {
+	warmedUp bool
+}
+
+type userService struct {
+	pool   *connectionPool
+	warmer *cacheWarmer
+}
+
+type cyclicPeaA struct {
+}
+
+type cyclicPeaB struct {
+}
+
+type parallelBarrier struct {
+	wg sync.WaitGroup
+}
+
+func (barrier *parallelBarrier) await() bool {
+	barrier.wg.Done()
+	done := make(chan struct{})
+	go func() {
+		barrier.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return true
+	case <-time.After(time.Second):
+		return false
+	}
+}
+
+func TestDefaultPeaFactory_PreInstantiateSharedPeasInParallel(t *testing.T) {
+	barrier := &parallelBarrier{}
+	barrier.wg.Add(2)
+
+	peaFactory := NewDefaultPeaFactory(WithParallelPreInstantiation(4))
+	peaFactory.RegisterPeaDefinition("userService", NewSimplePeaDefinition(goo.GetType(func(pool *connectionPool, warmer *cacheWarmer) *userService {
+		return &userService{pool, warmer}
+	})))
+	peaFactory.RegisterPeaDefinition("connectionPool", NewSimplePeaDefinition(goo.GetType(func() *connectionPool {
+		return &connectionPool{barrier.await()}
+	})))
+	peaFactory.RegisterPeaDefinition("cacheWarmer", NewSimplePeaDefinition(goo.GetType(func() *cacheWarmer {
+		return &cacheWarmer{barrier.await()}
+	})))
+	peaFactory.RegisterPeaDefinition("prototypePea", NewSimplePeaDefinition(goo.GetType(testStruct{}), WithScope(PrototypeScope)))
+
+	err := peaFactory.PreInstantiateSharedPeas()
+	assert.Nil(t, err)
+	assert.Equal(t, 3, peaFactory.GetSharedPeaCount())
+	assert.False(t, peaFactory.ContainsPea("prototypePea"))
+
+	service := peaFactory.GetSharedPea("userService").(*userService)
+	assert.True(t, service.pool.warmedUp)
+	assert.True(t, service.warmer.warmedUp)
+	assert.Equal(t, peaFactory.GetSharedPea("connectionPool"), service.pool)
+	assert.Equal(t, peaFactory.GetSharedPea("cacheWarmer"), service.warmer)
+}
+
+func TestDefaultPeaFactory_PreInstantiateSharedPeasInParallelWithCycle(t *testing.T) {
+	peaFactory := NewDefaultPeaFactory(WithParallelPreInstantiation(2))
+	peaFactory.RegisterPeaDefinition("cyclicPeaA", NewSimplePeaDefinition(goo.GetType(func(b cyclicPeaB) cyclicPeaA {
+		return cyclicPeaA{}
+	})))
+	peaFactory.RegisterPeaDefinition("cyclicPeaB", NewSimplePeaDefinition(goo.GetType(func(a cyclicPeaA) cyclicPeaB {
+		return cyclicPeaB{}
+	}), WithScope(PrototypeScope)))
+
+	err := peaFactory.PreInstantiateSharedPeas()
+	assert.NotNil(t, err)
+	assert.Equal(t, "cyclicPeaA : circular dependency cycle : cyclicPeaA -> cyclicPeaB -> cyclicPeaA", err.Error())
+	assert.Equal(t, 0, peaFactory.GetSharedPeaCount())
+}
+
+func TestDefaultPeaFactory_PreInstantiateSharedPeasInParallelWhenPeaFails(t *testing.T) {
+	peaFactory := NewDefaultPeaFactory(WithParallelPreInstantiation(2))
+	peaFactory.RegisterPeaDefinition("connectionPool", NewSimplePeaDefinition(goo.GetType(func() *connectionPool {
+		return &connectionPool{}
+	})))
+	peaFactory.RegisterPeaDefinition("cacheWarmer", NewSimplePeaDefinition(goo.GetType(func() *cacheWarmer {
+		panic("cache is not reachable")
+	})))
+	peaFactory.RegisterPeaDefinition("userService", NewSimplePeaDefinition(goo.GetType(func(pool *connectionPool, warmer *cacheWarmer) *userService {
+		return &userService{pool, warmer}
+	})))
+
+	err := peaFactory.PreInstantiateSharedPeas()
+	assert.NotNil(t, err)
+	assert.Equal(t, "cacheWarmer : cache is not reachable", err.Error())
+	assert.False(t, peaFactory.ContainsPea("userService"))
+}
+
+func TestDefaultPeaFactory_PreInstantiateSharedPeasInParallelInChildFactory(t *testing.T) {
+	var creationCount int32
+	parentFactory := NewDefaultPeaFactory()
+	parentFactory.RegisterPeaDefinition("connectionPool", NewSimplePeaDefinition(goo.GetType(func() *connectionPool {
+		atomic.AddInt32(&creationCount, 1)
+		time.Sleep(20 * time.Millisecond)
+		return &connectionPool{true}
+	}), WithLazyInit()))
+
+	peaFactory := NewChildPeaFactory(parentFactory, WithParallelPreInstantiation(4))
+	newUserService := func(pool *connectionPool) *userService {
+		return &userService{pool: pool}
+	}
+	peaFactory.RegisterPeaDefinition("userService", NewSimplePeaDefinition(goo.GetType(newUserService)))
+	peaFactory.RegisterPeaDefinition("adminService", NewSimplePeaDefinition(goo.GetType(newUserService)))
+
+	err := peaFactory.PreInstantiateSharedPeas()
+	assert.Nil(t, err)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&creationCount))
+
+	pool := parentFactory.GetSharedPea("connectionPool")
+	assert.NotNil(t, pool)
+	assert.Same(t, pool, peaFactory.GetSharedPea("userService").(*userService).pool)
+	assert.Same(t, pool, peaFactory.GetSharedPea("adminService").(*userService).pool)
+}
+
+func TestDefaultPeaFactory_PreInstantiateSharedPeasInChildFactoryWhenParentPeaFails(t *testing.T) {
+	parentFactory := NewDefaultPeaFactory()
+	parentFactory.RegisterPeaDefinition("connectionPool", NewSimplePeaDefinition(goo.GetType(func() (*connectionPool, error) {
+		return nil, errors.New("database is not reachable")
+	}), WithLazyInit()))
+
+	for _, workerCount := range []int{0, 4} {
+		peaFactory := NewChildPeaFactory(parentFactory, WithParallelPreInstantiation(workerCount))
+		peaFactory.RegisterPeaDefinition("userService", NewSimplePeaDefinition(goo.GetType(func(pool *connectionPool) *userService {
+			return &userService{pool: pool}
+		})))
+
+		err := peaFactory.PreInstantiateSharedPeas()
+		assert.NotNil(t, err)
+		assert.False(t, peaFactory.ContainsSharedPea("userService"))
+	}
+}
+
+func TestDefaultPeaFactory_PreInstantiateSharedPeasInParallelWhenProcessorFails(t *testing.T) {
+	peaFactory := NewDefaultPeaFactory(WithParallelPreInstantiation(2))
+	peaFactory.RegisterPeaDefinition("connectionPool", NewSimplePeaDefinition(goo.GetType(func() *connectionPool {
+		return &connectionPool{}
+	})))
+	peaFactory.AddPeaProcessor(&testPeaProcessor{errBeforePeaInitialization: errors.New("pea error")})
+
+	err := peaFactory.PreInstantiateSharedPeas()
+	assert.NotNil(t, err)
+	assert.Equal(t, "pea error", creationErrorCause(t, err).Error())
+}
@@ -0,0 +1,166 @@
+package peas
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strconv"
+	"time"
+)
+
+const DefaultLifecyclePhaseTimeout = 30 * time.Second
+
+type LifecyclePea interface {
+	Start(ctx context.Context) error
+	Stop(ctx context.Context) error
+	IsRunning() bool
+}
+
+type PhasedPea interface {
+	Phase() int
+}
+
+type lifecyclePhase struct {
+	phase    int
+	peaNames []string
+	peas     []LifecyclePea
+}
+
+func getLifecyclePhase(pea interface{}) int {
+	if phasedPea, ok := pea.(PhasedPea); ok {
+		return phasedPea.Phase()
+	}
+	return 0
+}
+
+// Start starts the lifecycle peas phase by phase. If a phase cannot be started, the peas already started
+// in that phase and the earlier phases are stopped in reverse order, and the start error is returned.
+func (factory DefaultPeaFactory) Start(ctx context.Context) error {
+	phases := factory.getLifecyclePhases()
+	for index, phase := range phases {
+		err := factory.startLifecyclePhase(ctx, phase)
+		if err != nil {
+			for ; index >= 0; index-- {
+				factory.stopLifecyclePhase(context.Background(), phases[index])
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+func (factory DefaultPeaFactory) Stop(ctx context.Context) error {
+	var result error
+	phases := factory.getLifecyclePhases()
+	for index := len(phases) - 1; index >= 0; index-- {
+		err := factory.stopLifecyclePhase(ctx, phases[index])
+		if err != nil && result == nil {
+			result = err
+		}
+	}
+	return result
+}
+
+func (factory DefaultPeaFactory) getLifecyclePhases() []*lifecyclePhase {
+	phaseMap := make(map[int]*lifecyclePhase, 0)
+	phases := make([]*lifecyclePhase, 0)
+
+	peaNames := factory.dependencies.sortByDependencies(factory.GetSharedPeaNames())
+	for _, peaName := range peaNames {
+		lifecyclePea, ok := factory.GetSharedPea(peaName).(LifecyclePea)
+		if !ok {
+			continue
+		}
+
+		phaseNumber := getLifecyclePhase(lifecyclePea)
+		phase, ok := phaseMap[phaseNumber]
+		if !ok {
+			phase = &lifecyclePhase{
+				phase: phaseNumber,
+			}
+			phaseMap[phaseNumber] = phase
+			phases = append(phases, phase)
+		}
+
+		phase.peaNames = append(phase.peaNames, peaName)
+		phase.peas = append(phase.peas, lifecyclePea)
+	}
+
+	sort.SliceStable(phases, func(i, j int) bool {
+		return phases[i].phase < phases[j].phase
+	})
+	return phases
+}
+
+func (factory DefaultPeaFactory) startLifecyclePhase(ctx context.Context, phase *lifecyclePhase) error {
+	return factory.runLifecyclePhase(ctx, phase, "started", func(phaseCtx context.Context) error {
+		for index, pea := range phase.peas {
+			if phaseCtx.Err() != nil {
+				return phaseCtx.Err()
+			} else if pea.IsRunning() {
+				continue
+			}
+
+			if err := pea.Start(phaseCtx); err != nil {
+				return NewPeaPreparationError(phase.peaNames[index], "pea could not be started : "+err.Error())
+			}
+		}
+		return nil
+	})
+}
+
+func (factory DefaultPeaFactory) stopLifecyclePhase(ctx context.Context, phase *lifecyclePhase) error {
+	return factory.runLifecyclePhase(ctx, phase, "stopped", func(phaseCtx context.Context) error {
+		var result error
+		for index := len(phase.peas) - 1; index >= 0; index-- {
+			pea := phase.peas[index]
+			if phaseCtx.Err() != nil {
+				return phaseCtx.Err()
+			} else if !pea.IsRunning() {
+				continue
+			}
+
+			if err := pea.Stop(phaseCtx); err != nil && result == nil {
+				result = NewPeaPreparationError(phase.peaNames[index], "pea could not be stopped : "+err.Error())
+			}
+		}
+		return result
+	})
+}
+
+// runLifecyclePhase runs the phase function within the phase timeout. When the timeout expires, the error is returned
+// without waiting for the phase function. No other pea is started or stopped after that, but the pea being started or
+// stopped at that moment keeps running until it returns, so Start and Stop must honour the given context.
+func (factory DefaultPeaFactory) runLifecyclePhase(ctx context.Context,
+	phase *lifecyclePhase,
+	action string,
+	phaseFunc func(phaseCtx context.Context) error) error {
+
+	phaseCtx := ctx
+	if factory.lifecyclePhaseTimeout > 0 {
+		var cancel context.CancelFunc
+		phaseCtx, cancel = context.WithTimeout(ctx, factory.lifecyclePhaseTimeout)
+		defer cancel()
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- phaseFunc(phaseCtx)
+	}()
+
+	select {
+	case err := <-done:
+		if err == nil || phaseCtx.Err() == nil {
+			return err
+		}
+	case <-phaseCtx.Done():
+		select {
+		case err := <-done:
+			if err == nil {
+				return nil
+			}
+		default:
+		}
+	}
+	return errors.New("lifecycle phase " + strconv.Itoa(phase.phase) + " could not be " + action + " in time : " + phaseCtx.Err().Error())
+}
@@ -0,0 +1,177 @@
+package peas
+
+import (
+	"context"
+	"errors"
+	"github.com/procyon-projects/goo"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+type lifecycleEvents struct {
+	events []string
+}
+
+func (events *lifecycleEvents) add(event string) {
+	events.events = append(events.events, event)
+}
+
+type testLifecyclePea struct {
+	name      string
+	phase     int
+	events    *lifecycleEvents
+	running   bool
+	startErr  error
+	delay     time.Duration
+	stopDelay time.Duration
+}
+
+func (pea *testLifecyclePea) Start(ctx context.Context) error {
+	if pea.delay > 0 {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(pea.delay):
+		}
+	}
+	if pea.startErr != nil {
+		return pea.startErr
+	}
+	pea.running = true
+	pea.events.add("start:" + pea.name)
+	return nil
+}
+
+func (pea *testLifecyclePea) Stop(ctx context.Context) error {
+	if pea.stopDelay > 0 {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(pea.stopDelay):
+		}
+	}
+	pea.running = false
+	pea.events.add("stop:" + pea.name)
+	return nil
+}
+
+func (pea *testLifecyclePea) IsRunning() bool {
+	return pea.running
+}
+
+func (pea *testLifecyclePea) Phase() int {
+	return pea.phase
+}
+
+type serverPea struct {
+	*testLifecyclePea
+}
+
+type consumerPea struct {
+	*testLifecyclePea
+}
+
+type schedulerPea struct {
+	*testLifecyclePea
+}
+
+func TestDefaultPeaFactory_StartAndStopInDependencyOrder(t *testing.T) {
+	events := &lifecycleEvents{}
+	peaFactory := NewDefaultPeaFactory()
+
+	peaFactory.RegisterPeaDefinition("server", NewSimplePeaDefinition(goo.GetType(func(consumer consumerPea) serverPea {
+		return serverPea{&testLifecyclePea{name: "server", events: events}}
+	})))
+	peaFactory.RegisterPeaDefinition("consumer", NewSimplePeaDefinition(goo.GetType(func() consumerPea {
+		return consumerPea{&testLifecyclePea{name: "consumer", events: events}}
+	})))
+	peaFactory.RegisterPeaDefinition("scheduler", NewSimplePeaDefinition(goo.GetType(func() schedulerPea {
+		return schedulerPea{&testLifecyclePea{name: "scheduler", phase: -1, events: events}}
+	})))
+	peaFactory.PreInstantiateSharedPeas()
+
+	err := peaFactory.Start(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"start:scheduler", "start:consumer", "start:server"}, events.events)
+
+	events.events = nil
+	err = peaFactory.Start(context.Background())
+	assert.Nil(t, err)
+	assert.Empty(t, events.events)
+
+	err = peaFactory.Stop(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"stop:server", "stop:consumer", "stop:scheduler"}, events.events)
+}
+
+func TestDefaultPeaFactory_StartWhenPeaFails(t *testing.T) {
+	events := &lifecycleEvents{}
+	peaFactory := NewDefaultPeaFactory()
+	peaFactory.RegisterSharedPea("server", serverPea{&testLifecyclePea{
+		name:     "server",
+		events:   events,
+		startErr: errors.New("address already in use"),
+	}})
+
+	err := peaFactory.Start(context.Background())
+	assert.NotNil(t, err)
+	assert.Equal(t, "server : pea could not be started : address already in use", err.Error())
+}
+
+func TestDefaultPeaFactory_StartStopsStartedPeasWhenPeaFails(t *testing.T) {
+	events := &lifecycleEvents{}
+	peaFactory := NewDefaultPeaFactory()
+	peaFactory.RegisterSharedPea("scheduler", schedulerPea{&testLifecyclePea{name: "scheduler", phase: -1, events: events}})
+	peaFactory.RegisterSharedPea("consumer", consumerPea{&testLifecyclePea{name: "consumer", events: events}})
+	peaFactory.RegisterSharedPea("server", serverPea{&testLifecyclePea{
+		name:     "server",
+		events:   events,
+		startErr: errors.New("address already in use"),
+	}})
+
+	err := peaFactory.Start(context.Background())
+	assert.NotNil(t, err)
+	assert.Equal(t, "server : pea could not be started : address already in use", err.Error())
+	assert.Equal(t, []string{"start:scheduler", "start:consumer", "stop:consumer", "stop:scheduler"}, events.events)
+}
+
+func TestDefaultPeaFactory_StartWithPhaseTimeout(t *testing.T) {
+	events := &lifecycleEvents{}
+	peaFactory := NewDefaultPeaFactory(WithLifecyclePhaseTimeout(10 * time.Millisecond))
+	peaFactory.RegisterSharedPea("consumer", consumerPea{&testLifecyclePea{name: "consumer", events: events}})
+	peaFactory.RegisterSharedPea("server", serverPea{&testLifecyclePea{
+		name:   "server",
+		phase:  2,
+		events: events,
+		delay:  time.Second,
+	}})
+
+	err := peaFactory.Start(context.Background())
+	assert.NotNil(t, err)
+	assert.Equal(t, "lifecycle phase 2 could not be started in time : context deadline exceeded", err.Error())
+	assert.Equal(t, []string{"start:consumer", "stop:consumer"}, events.events)
+}
+
+func TestDefaultPeaFactory_StopWithPhaseTimeout(t *testing.T) {
+	events := &lifecycleEvents{}
+	peaFactory := NewDefaultPeaFactory(WithLifecyclePhaseTimeout(10 * time.Millisecond))
+	scheduler := &testLifecyclePea{name: "scheduler", phase: -1, events: events}
+	consumer := &testLifecyclePea{name: "consumer", events: events}
+	server := &testLifecyclePea{name: "server", phase: 2, events: events, stopDelay: time.Second}
+	peaFactory.RegisterSharedPea("scheduler", schedulerPea{scheduler})
+	peaFactory.RegisterSharedPea("consumer", consumerPea{consumer})
+	peaFactory.RegisterSharedPea("server", serverPea{server})
+
+	err := peaFactory.Start(context.Background())
+	assert.Nil(t, err)
+
+	events.events = nil
+	err = peaFactory.Stop(context.Background())
+	assert.NotNil(t, err)
+	assert.Equal(t, "lifecycle phase 2 could not be stopped in time : context deadline exceeded", err.Error())
+	assert.Equal(t, []string{"stop:consumer", "stop:scheduler"}, events.events)
+	assert.False(t, consumer.IsRunning())
+	assert.False(t, scheduler.IsRunning())
+	assert.True(t, server.IsRunning())
+}
@@ -0,0 +1,94 @@
+package peas
+
+import (
+	"github.com/procyon-projects/goo"
+)
+
+type ListablePeaFactory interface {
+	PeaFactory
+	GetPeasOfType(typ goo.Type, includePrototypes bool, allowEagerInit bool) (map[string]interface{}, error)
+	GetPeaNamesForType(typ goo.Type, includeNonShared bool) []string
+}
+
+func (factory DefaultPeaFactory) GetPeaNamesForType(typ goo.Type, includeNonShared bool) []string {
+	if typ == nil {
+		panic("Required type must not be nil")
+	}
+
+	peaNames := factory.getLocalPeaNamesForType(typ, includeNonShared)
+
+	if parent, ok := factory.parent.(ListablePeaFactory); ok {
+		for _, peaName := range parent.GetPeaNamesForType(typ, includeNonShared) {
+			if !factory.isLocalPea(peaName) {
+				peaNames = append(peaNames, peaName)
+			}
+		}
+	}
+
+	return peaNames
+}
+
+func (factory DefaultPeaFactory) getLocalPeaNamesForType(typ goo.Type, includeNonShared bool) []string {
+	processedPeaNames := make(map[string]bool, 0)
+	peaNames := make([]string, 0)
+
+	for _, peaName := range factory.PeaDefinitionRegistry.GetPeaNamesByType(typ) {
+		peaDefinition := factory.getMergedPeaDefinition(peaName)
+		if !factory.isActivePeaDefinition(peaDefinition) || (!includeNonShared && peaDefinition.GetScope() != SharedScope) {
+			continue
+		}
+		peaNames = append(peaNames, peaName)
+		processedPeaNames[peaName] = true
+	}
+
+	for _, peaName := range factory.GetSharedPeaNamesByType(typ) {
+		if processedPeaNames[peaName] {
+			continue
+		}
+		peaNames = append(peaNames, peaName)
+		processedPeaNames[peaName] = true
+	}
+
+	return peaNames
+}
+
+func (factory DefaultPeaFactory) GetPeasOfType(typ goo.Type, includePrototypes bool, allowEagerInit bool) (map[string]interface{}, error) {
+	if typ == nil {
+		panic("Required type must not be nil")
+	}
+
+	factory.evaluatePendingConditions()
+	peas := make(map[string]interface{}, 0)
+	for _, peaName := range factory.getLocalPeaNamesForType(typ, includePrototypes) {
+		if sharedPea := factory.GetSharedPea(peaName); sharedPea != nil {
+			peas[peaName] = sharedPea
+			continue
+		}
+
+		peaDefinition := factory.getMergedPeaDefinition(peaName)
+		if peaDefinition == nil || (peaDefinition.GetScope() == SharedScope && !allowEagerInit) {
+			continue
+		}
+
+		pea, err := factory.GetPea(peaName)
+		if err != nil {
+			return nil, err
+		}
+		peas[peaName] = pea
+	}
+
+	if parent, ok := factory.parent.(ListablePeaFactory); ok {
+		parentPeas, err := parent.GetPeasOfType(typ, includePrototypes, allowEagerInit)
+		if err != nil {
+			return nil, err
+		}
+
+		for peaName, pea := range parentPeas {
+			if !factory.isLocalPea(peaName) {
+				peas[peaName] = pea
+			}
+		}
+	}
+
+	return peas, nil
+}
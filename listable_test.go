@@ -0,0 +1,63 @@
+package peas
+
+import (
+	"github.com/procyon-projects/goo"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestDefaultPeaFactory_GetPeaNamesForType(t *testing.T) {
+	peaFactory := NewDefaultPeaFactory()
+	peaFactory.RegisterPeaDefinition("sharedPea", NewSimplePeaDefinition(goo.GetType(testStruct{})))
+	peaFactory.RegisterPeaDefinition("prototypePea", NewSimplePeaDefinition(goo.GetType(testStruct{}), WithScope(PrototypeScope)))
+	peaFactory.RegisterSharedPea("manualPea", testStruct{})
+	peaFactory.RegisterSharedPea("anotherPea", testStruct2{})
+
+	testInterfaceType := goo.GetType((*testInterface)(nil))
+	assert.Equal(t, []string{"sharedPea", "manualPea"}, peaFactory.GetPeaNamesForType(testInterfaceType, false))
+	assert.Equal(t, []string{"sharedPea", "prototypePea", "manualPea"}, peaFactory.GetPeaNamesForType(testInterfaceType, true))
+	assert.Equal(t, []string{"anotherPea"}, peaFactory.GetPeaNamesForType(goo.GetType(testStruct2{}), true))
+}
+
+func TestDefaultPeaFactory_GetPeasOfType(t *testing.T) {
+	peaFactory := NewDefaultPeaFactory()
+	peaFactory.RegisterPeaDefinition("sharedPea", NewSimplePeaDefinition(goo.GetType(testStruct{})))
+	peaFactory.RegisterPeaDefinition("prototypePea", NewSimplePeaDefinition(goo.GetType(testStruct{}), WithScope(PrototypeScope)))
+	peaFactory.RegisterSharedPea("manualPea", testStruct{})
+
+	testInterfaceType := goo.GetType((*testInterface)(nil))
+	peas, err := peaFactory.GetPeasOfType(testInterfaceType, false, false)
+	assert.Nil(t, err)
+	assert.Len(t, peas, 1)
+	assert.Contains(t, peas, "manualPea")
+	assert.False(t, peaFactory.ContainsSharedPea("sharedPea"))
+
+	peas, err = peaFactory.GetPeasOfType(testInterfaceType, true, true)
+	assert.Nil(t, err)
+	assert.Len(t, peas, 3)
+	assert.Contains(t, peas, "sharedPea")
+	assert.Contains(t, peas, "prototypePea")
+	assert.Contains(t, peas, "manualPea")
+	assert.True(t, peaFactory.ContainsSharedPea("sharedPea"))
+	assert.False(t, peaFactory.ContainsSharedPea("prototypePea"))
+}
+
+func TestDefaultPeaFactory_GetPeasOfTypeWithParent(t *testing.T) {
+	parentFactory := NewDefaultPeaFactory()
+	parentFactory.RegisterSharedPea("parentPea", testStruct{})
+	parentFactory.RegisterSharedPea("shadowedPea", testStruct{})
+
+	childFactory := NewChildPeaFactory(parentFactory)
+	childFactory.RegisterSharedPea("shadowedPea", testStruct2{})
+	childFactory.RegisterSharedPea("childPea", testStruct{})
+
+	testInterfaceType := goo.GetType((*testInterface)(nil))
+	assert.Equal(t, []string{"childPea", "parentPea"}, childFactory.GetPeaNamesForType(testInterfaceType, false))
+	assert.Equal(t, []string{"parentPea", "shadowedPea"}, parentFactory.GetPeaNamesForType(testInterfaceType, false))
+
+	peas, err := childFactory.GetPeasOfType(testInterfaceType, false, true)
+	assert.Nil(t, err)
+	assert.Len(t, peas, 2)
+	assert.Contains(t, peas, "childPea")
+	assert.Contains(t, peas, "parentPea")
+}
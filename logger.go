@@ -0,0 +1,18 @@
+package peas
+
+import "log"
+
+type Logger interface {
+	Warn(message string)
+}
+
+type DefaultLogger struct {
+}
+
+func NewDefaultLogger() DefaultLogger {
+	return DefaultLogger{}
+}
+
+func (logger DefaultLogger) Warn(message string) {
+	log.Println("WARN " + message)
+}
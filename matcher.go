@@ -0,0 +1,114 @@
+package peas
+
+import (
+	"errors"
+	"fmt"
+	"github.com/procyon-projects/goo"
+	"reflect"
+)
+
+type TypeMatcher interface {
+	Matches(peaType goo.Type, requiredType goo.Type) bool
+}
+
+type typeMatcherAware interface {
+	SetTypeMatcher(matcher TypeMatcher)
+}
+
+// DefaultTypeMatcher decides whether a pea of the given type can be used where the required type is expected.
+//
+// A pea type which is a function is a constructor, so it is matched by the type it returns. A constructor can return
+// an error after the pea, constructors having any other return values never match.
+// A pointer and a value of the same type match each other. An interface is matched by the interfaces extending it
+// and by the types implementing it, the methods with pointer receivers are taken into account only for pointer peas.
+// A struct is matched by the structs embedding it. Any other type is matched by the types assignable to it.
+type DefaultTypeMatcher struct {
+}
+
+func NewDefaultTypeMatcher() DefaultTypeMatcher {
+	return DefaultTypeMatcher{}
+}
+
+func (matcher DefaultTypeMatcher) Matches(peaType goo.Type, requiredType goo.Type) bool {
+	if peaType == nil || requiredType == nil {
+		return false
+	}
+
+	if peaType.IsFunction() {
+		var ok bool
+		if peaType, ok = getConstructorReturnType(peaType.ToFunctionType()); !ok {
+			return false
+		}
+	}
+
+	if peaType.GetGoType() == requiredType.GetGoType() {
+		return true
+	}
+
+	if requiredType.IsInterface() {
+		return getActualGoType(peaType).Implements(requiredType.GetGoType())
+	} else if requiredType.IsStruct() {
+		return peaType.IsStruct() && peaType.ToStructType().EmbeddedStruct(requiredType.ToStructType())
+	}
+
+	return getActualGoType(peaType).AssignableTo(getActualGoType(requiredType))
+}
+
+func matchesInstanceType(matcher TypeMatcher, instanceType goo.Type, requiredType goo.Type) bool {
+	if instanceType != nil && requiredType != nil && instanceType.IsFunction() {
+		return getActualGoType(instanceType).AssignableTo(getActualGoType(requiredType))
+	}
+	return matcher.Matches(instanceType, requiredType)
+}
+
+func matchesDefinition(matcher TypeMatcher, definition PeaDefinition, requiredType goo.Type) bool {
+	if exposedTypes := definition.GetExposedTypes(); len(exposedTypes) != 0 {
+		return matchesAnyType(matcher, exposedTypes, requiredType)
+	}
+	return matcher.Matches(definition.GetPeaType(), requiredType)
+}
+
+func matchesAnyType(matcher TypeMatcher, exposedTypes []goo.Type, requiredType goo.Type) bool {
+	for _, exposedType := range exposedTypes {
+		if matchesInstanceType(matcher, exposedType, requiredType) {
+			return true
+		}
+	}
+	return false
+}
+
+// isPredeclaredType returns true for the types such as string and int. The parameters of these types are not
+// resolved from the peas, since they would match any unrelated pea of the same type. They can be given by
+// WithArgument, or a named type such as time.Duration can be used instead.
+func isPredeclaredType(typ goo.Type) bool {
+	goType := getActualGoType(typ)
+	if goType.Name() == "" || goType.PkgPath() != "" {
+		return false
+	}
+
+	switch goType.Kind() {
+	case reflect.Bool, reflect.String,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64, reflect.Complex64, reflect.Complex128:
+		return true
+	}
+	return false
+}
+
+func getActualGoType(typ goo.Type) reflect.Type {
+	if typ.IsPointer() {
+		return reflect.PtrTo(typ.GetGoType())
+	}
+	return typ.GetGoType()
+}
+
+func getInstanceType(instance interface{}) (typ goo.Type, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = errors.New("pea object type is not supported : " + fmt.Sprint(r))
+		}
+	}()
+	typ = goo.GetType(instance)
+	return
+}
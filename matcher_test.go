@@ -0,0 +1,105 @@
+package peas
+
+import (
+	"github.com/procyon-projects/goo"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+type pointerReceiverStruct struct {
+}
+
+func (*pointerReceiverStruct) testMethod() {
+
+}
+
+type extendedTestInterface interface {
+	testInterface
+	anotherTestMethod()
+}
+
+func newTestInterface() testInterface {
+	return testStruct{}
+}
+
+func newExtendedTestInterface() extendedTestInterface {
+	return nil
+}
+
+type nothingMatcher struct {
+}
+
+func (matcher nothingMatcher) Matches(peaType goo.Type, requiredType goo.Type) bool {
+	return false
+}
+
+func TestDefaultTypeMatcher_Matches(t *testing.T) {
+	matcher := NewDefaultTypeMatcher()
+	testInterfaceType := goo.GetType((*testInterface)(nil))
+
+	assert.True(t, matcher.Matches(goo.GetType(testStruct{}), goo.GetType(testStruct{})))
+	assert.True(t, matcher.Matches(goo.GetType(&testStruct{}), goo.GetType(testStruct{})))
+	assert.True(t, matcher.Matches(goo.GetType(testStruct{}), goo.GetType(&testStruct{})))
+	assert.False(t, matcher.Matches(goo.GetType(testStruct2{}), goo.GetType(testStruct{})))
+	assert.False(t, matcher.Matches(nil, goo.GetType(testStruct{})))
+	assert.False(t, matcher.Matches(goo.GetType(testStruct{}), nil))
+
+	assert.True(t, matcher.Matches(goo.GetType(testStruct{}), testInterfaceType))
+	assert.True(t, matcher.Matches(goo.GetType(&testStruct{}), testInterfaceType))
+	assert.True(t, matcher.Matches(goo.GetType(&pointerReceiverStruct{}), testInterfaceType))
+	assert.False(t, matcher.Matches(goo.GetType(pointerReceiverStruct{}), testInterfaceType))
+	assert.False(t, matcher.Matches(goo.GetType(testStruct2{}), testInterfaceType))
+
+	assert.True(t, matcher.Matches(goo.GetType(newTestInterface), testInterfaceType))
+	assert.True(t, matcher.Matches(goo.GetType(newExtendedTestInterface), testInterfaceType))
+	assert.False(t, matcher.Matches(goo.GetType(newTestInterface), goo.GetType((*extendedTestInterface)(nil))))
+
+	assert.True(t, matcher.Matches(goo.GetType(testStruct{}), goo.GetType(baseTestStruct{})))
+	assert.False(t, matcher.Matches(goo.GetType(baseTestStruct{}), goo.GetType(testStruct{})))
+
+	assert.True(t, matcher.Matches(goo.GetType(newStructFunction), goo.GetType(testStruct{})))
+	assert.True(t, matcher.Matches(goo.GetType(newStructFunctionWithMoreReturnValuesThanOne), goo.GetType(testStruct{})))
+	assert.False(t, matcher.Matches(goo.GetType(newStructFunctionWithTwoValues), goo.GetType(testStruct{})))
+
+	assert.True(t, matcher.Matches(goo.GetType(""), goo.GetType("")))
+	assert.False(t, matcher.Matches(goo.GetType(0), goo.GetType(0.0)))
+	assert.True(t, matcher.Matches(goo.GetType([]string{}), goo.GetType([]string{})))
+	assert.True(t, matcher.Matches(goo.GetType(func() func() testStruct { return newStructFunction }), goo.GetType(newStructFunction)))
+	assert.False(t, matcher.Matches(goo.GetType(newStructFunction), goo.GetType(newStructFunction)))
+}
+
+func TestIsPredeclaredType(t *testing.T) {
+	assert.True(t, isPredeclaredType(goo.GetType("")))
+	assert.True(t, isPredeclaredType(goo.GetType(0)))
+	assert.True(t, isPredeclaredType(goo.GetType(false)))
+	assert.False(t, isPredeclaredType(goo.GetType(time.Duration(0))))
+	assert.False(t, isPredeclaredType(goo.GetType([]string{})))
+	assert.False(t, isPredeclaredType(goo.GetType(testStruct{})))
+}
+
+func TestDefaultPeaFactory_WithTypeMatcher(t *testing.T) {
+	sharedPeaRegistry := NewDefaultSharedPeaRegistry()
+	peaDefinitionRegistry := NewDefaultPeaDefinitionRegistry()
+	peaFactory := NewDefaultPeaFactory(WithTypeMatcher(nothingMatcher{}),
+		WithSharedPeaRegistry(sharedPeaRegistry),
+		WithPeaDefinitionRegistry(peaDefinitionRegistry))
+
+	assert.Equal(t, nothingMatcher{}, peaFactory.GetTypeMatcher())
+	assert.Equal(t, nothingMatcher{}, sharedPeaRegistry.GetTypeMatcher())
+	assert.Equal(t, nothingMatcher{}, peaDefinitionRegistry.GetTypeMatcher())
+
+	peaFactory.RegisterPeaDefinition("testPea", NewSimplePeaDefinition(goo.GetType(newStructFunction)))
+	peaFactory.RegisterSharedPea("sharedTestPea", testStruct{})
+	assert.Empty(t, peaFactory.GetPeaNamesByType(goo.GetType(testStruct{})))
+	assert.Empty(t, peaFactory.GetSharedPeaNamesByType(goo.GetType(testStruct{})))
+
+	_, err := peaFactory.GetPeaByNameAndType("testPea", goo.GetType(testStruct{}))
+	assert.NotNil(t, err)
+}
+
+func TestDefaultPeaDefinitionRegistry_GetPeaNamesByTypeForInterfaceConstructor(t *testing.T) {
+	registry := NewDefaultPeaDefinitionRegistry()
+	registry.RegisterPeaDefinition("testPea", NewSimplePeaDefinition(goo.GetType(newTestInterface)))
+	assert.Equal(t, []string{"testPea"}, registry.GetPeaNamesByType(goo.GetType((*testInterface)(nil))))
+}
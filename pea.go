@@ -1,24 +1,55 @@
 package peas
 
 import (
+	"context"
 	"github.com/procyon-projects/goo"
 )
 
 type ConfigurablePeaFactory interface {
 	SharedPeaRegistry
-	PeaFactory
+	ListablePeaFactory
 	RegisterTypeAsOnlyReadable(typ goo.Type) error
 	ExcludeType(typ goo.Type) error
 	AddPeaProcessor(processor PeaProcessor) error
 	GetPeaProcessors() []PeaProcessor
 	GetPeaProcessorsCount() int
-	PreInstantiateSharedPeas()
+	PreInstantiateSharedPeas() error
+	DestroySharedPeas() error
+	Start(ctx context.Context) error
+	Stop(ctx context.Context) error
 }
 
 type PeaInitializer interface {
 	InitializePea() error
 }
 
+type ContextPeaInitializer interface {
+	InitializePea(ctx context.Context) error
+}
+
+type PeaDestroyer interface {
+	DestroyPea() error
+}
+
+type PeaNameAware interface {
+	SetPeaName(name string)
+}
+
+type PeaFactoryAware interface {
+	SetPeaFactory(factory PeaFactory)
+}
+
+type PeaDefinitionAware interface {
+	SetPeaDefinition(definition PeaDefinition)
+}
+
 type PeaNameGenerator interface {
 	GenerateName(peaDefinition PeaDefinition) string
 }
+
+// RegistryAwarePeaNameGenerator is used instead of PeaNameGenerator by the registry if the generator implements it,
+// so that the generated name can be made unique among the names already registered.
+type RegistryAwarePeaNameGenerator interface {
+	PeaNameGenerator
+	GenerateUniqueName(peaDefinition PeaDefinition, registry PeaDefinitionRegistry) string
+}
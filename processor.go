@@ -12,13 +12,15 @@ type PeaProcessor interface {
 }
 
 type PeaProcessors struct {
-	processors map[string]PeaProcessor
-	mu         sync.RWMutex
+	processors     map[string]PeaProcessor
+	processorNames []string
+	mu             sync.RWMutex
 }
 
 func NewPeaProcessors() *PeaProcessors {
 	return &PeaProcessors{
 		make(map[string]PeaProcessor, 0),
+		make([]string, 0),
 		sync.RWMutex{},
 	}
 }
@@ -28,12 +30,13 @@ func (p *PeaProcessors) AddPeaProcessor(processor PeaProcessor) error {
 		return errors.New("processor cannot be null")
 	}
 	p.mu.Lock()
+	defer p.mu.Unlock()
 	processorType := goo.GetType(processor)
 	if _, ok := p.processors[processorType.GetFullName()]; ok {
 		return errors.New("You have already registered this processor : " + processorType.GetFullName())
 	}
 	p.processors[processorType.GetFullName()] = processor
-	p.mu.Unlock()
+	p.processorNames = append(p.processorNames, processorType.GetFullName())
 	return nil
 }
 
@@ -45,6 +48,7 @@ func (p *PeaProcessors) RemoveProcessor(processor PeaProcessor) {
 	processorType := goo.GetType(processor)
 	if _, ok := p.processors[processorType.GetFullName()]; ok {
 		delete(p.processors, processorType.GetFullName())
+		p.processorNames = removeString(p.processorNames, processorType.GetFullName())
 	}
 	p.mu.Unlock()
 }
@@ -52,8 +56,8 @@ func (p *PeaProcessors) RemoveProcessor(processor PeaProcessor) {
 func (p *PeaProcessors) GetProcessors() []PeaProcessor {
 	processors := make([]PeaProcessor, 0)
 	p.mu.Lock()
-	for _, val := range p.processors {
-		processors = append(processors, val)
+	for _, processorName := range p.processorNames {
+		processors = append(processors, p.processors[processorName])
 	}
 	p.mu.Unlock()
 	return processors
@@ -66,6 +70,7 @@ func (p *PeaProcessors) GetProcessorsCount() int {
 func (p *PeaProcessors) RemoveAllProcessor() {
 	p.mu.Lock()
 	p.processors = make(map[string]PeaProcessor, 0)
+	p.processorNames = make([]string, 0)
 	p.mu.Unlock()
 }
 
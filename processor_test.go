@@ -91,3 +91,21 @@ func TestPeaProcessors_GetProcessors(t *testing.T) {
 	assert.Nil(t, err)
 	assert.Equal(t, 1, len(peaProcessors.GetProcessors()))
 }
+
+type anotherTestPeaProcessor struct {
+	testPeaProcessor
+}
+
+func TestPeaProcessors_GetProcessorsInRegistrationOrder(t *testing.T) {
+	peaProcessors := NewPeaProcessors()
+	firstProcessor := &anotherTestPeaProcessor{}
+	secondProcessor := newTestPeaProcessor()
+	assert.Nil(t, peaProcessors.AddPeaProcessor(firstProcessor))
+	assert.Nil(t, peaProcessors.AddPeaProcessor(secondProcessor))
+	assert.NotNil(t, peaProcessors.AddPeaProcessor(firstProcessor))
+	assert.Equal(t, []PeaProcessor{firstProcessor, secondProcessor}, peaProcessors.GetProcessors())
+
+	peaProcessors.RemoveProcessor(firstProcessor)
+	assert.Nil(t, peaProcessors.AddPeaProcessor(firstProcessor))
+	assert.Equal(t, []PeaProcessor{secondProcessor, firstProcessor}, peaProcessors.GetProcessors())
+}
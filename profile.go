@@ -0,0 +1,278 @@
+package peas
+
+import (
+	"errors"
+	"strings"
+	"sync"
+)
+
+const (
+	ActiveProfilesProperty  = "peas.profiles.active"
+	DefaultProfilesProperty = "peas.profiles.default"
+	DefaultProfile          = "default"
+)
+
+type profileRegistry struct {
+	activeProfiles  []string
+	defaultProfiles []string
+	mu              sync.RWMutex
+}
+
+func newProfileRegistry() *profileRegistry {
+	return &profileRegistry{
+		mu: sync.RWMutex{},
+	}
+}
+
+func WithActiveProfiles(profiles ...string) PeaFactoryOption {
+	return func(factory *DefaultPeaFactory) {
+		factory.SetActiveProfiles(profiles...)
+	}
+}
+
+func WithDefaultProfiles(profiles ...string) PeaFactoryOption {
+	return func(factory *DefaultPeaFactory) {
+		factory.SetDefaultProfiles(profiles...)
+	}
+}
+
+func (factory DefaultPeaFactory) SetActiveProfiles(profiles ...string) {
+	factory.profiles.mu.Lock()
+	factory.profiles.activeProfiles = normalizeProfiles(profiles)
+	factory.profiles.mu.Unlock()
+}
+
+// GetActiveProfiles returns the profiles set on the factory. If they are not set, the profiles are read from
+// the environment property peas.profiles.active, which can also be given as PEAS_PROFILES_ACTIVE.
+func (factory DefaultPeaFactory) GetActiveProfiles() []string {
+	factory.profiles.mu.RLock()
+	activeProfiles := factory.profiles.activeProfiles
+	factory.profiles.mu.RUnlock()
+
+	if activeProfiles != nil {
+		return append(make([]string, 0, len(activeProfiles)), activeProfiles...)
+	}
+
+	return factory.getProfilesProperty(ActiveProfilesProperty)
+}
+
+func (factory DefaultPeaFactory) SetDefaultProfiles(profiles ...string) {
+	factory.profiles.mu.Lock()
+	factory.profiles.defaultProfiles = normalizeProfiles(profiles)
+	factory.profiles.mu.Unlock()
+}
+
+// GetDefaultProfiles returns the profiles which are active when no profile is active. If they are not set on
+// the factory or by the environment property peas.profiles.default, the default profile is "default".
+func (factory DefaultPeaFactory) GetDefaultProfiles() []string {
+	factory.profiles.mu.RLock()
+	defaultProfiles := factory.profiles.defaultProfiles
+	factory.profiles.mu.RUnlock()
+
+	if defaultProfiles != nil {
+		return append(make([]string, 0, len(defaultProfiles)), defaultProfiles...)
+	}
+
+	if defaultProfiles = factory.getProfilesProperty(DefaultProfilesProperty); len(defaultProfiles) != 0 {
+		return defaultProfiles
+	}
+	return []string{DefaultProfile}
+}
+
+func (factory DefaultPeaFactory) getProfilesProperty(key string) []string {
+	if factory.environment == nil {
+		return []string{}
+	}
+
+	value, ok := factory.environment.GetProperty(key)
+	if !ok {
+		return []string{}
+	}
+	return normalizeProfiles(strings.Split(value, ","))
+}
+
+// AcceptsProfiles returns true if one of the given profile expressions matches the active profiles,
+// or the default profiles when no profile is active. The invalid expressions never match.
+func (factory DefaultPeaFactory) AcceptsProfiles(expressions ...string) bool {
+	profiles := factory.GetActiveProfiles()
+	if len(profiles) == 0 {
+		profiles = factory.GetDefaultProfiles()
+	}
+
+	isActive := func(profile string) bool {
+		return containsString(profiles, profile)
+	}
+
+	for _, expression := range expressions {
+		matcher, err := parseProfileExpression(expression)
+		if err == nil && matcher(isActive) {
+			return true
+		}
+	}
+	return false
+}
+
+func (factory DefaultPeaFactory) isActivePeaDefinition(definition PeaDefinition) bool {
+	return definition != nil && (len(definition.GetProfiles()) == 0 || factory.AcceptsProfiles(definition.GetProfiles()...))
+}
+
+func (factory DefaultPeaFactory) filterActivePeaNames(peaNames []string) []string {
+	activePeaNames := make([]string, 0, len(peaNames))
+	for _, peaName := range peaNames {
+		if factory.isActivePeaDefinition(factory.getMergedPeaDefinition(peaName)) {
+			activePeaNames = append(activePeaNames, peaName)
+		}
+	}
+	return activePeaNames
+}
+
+func normalizeProfiles(profiles []string) []string {
+	normalizedProfiles := make([]string, 0, len(profiles))
+	for _, profile := range profiles {
+		profile = strings.TrimSpace(profile)
+		if profile != "" && !containsString(normalizedProfiles, profile) {
+			normalizedProfiles = append(normalizedProfiles, profile)
+		}
+	}
+	return normalizedProfiles
+}
+
+type profileMatcher func(isActive func(profile string) bool) bool
+
+// parseProfileExpression parses the expressions consisting of profile names, the operators '!', '&', '|'
+// and parentheses. '&' takes precedence over '|'.
+func parseProfileExpression(expression string) (profileMatcher, error) {
+	parser := &profileExpressionParser{
+		expression: expression,
+		tokens:     tokenizeProfileExpression(expression),
+	}
+
+	if len(parser.tokens) == 0 {
+		return nil, errors.New("profile expression must not be empty")
+	}
+
+	matcher, err := parser.parseOr()
+	if err != nil {
+		return nil, err
+	}
+
+	if parser.position != len(parser.tokens) {
+		return nil, errors.New("unexpected '" + parser.tokens[parser.position] + "' in profile expression : " + expression)
+	}
+	return matcher, nil
+}
+
+type profileExpressionParser struct {
+	expression string
+	tokens     []string
+	position   int
+}
+
+func (parser *profileExpressionParser) peek() string {
+	if parser.position < len(parser.tokens) {
+		return parser.tokens[parser.position]
+	}
+	return ""
+}
+
+func (parser *profileExpressionParser) parseOr() (profileMatcher, error) {
+	left, err := parser.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+
+	for parser.peek() == "|" {
+		parser.position++
+		right, err := parser.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = orProfileMatcher(left, right)
+	}
+	return left, nil
+}
+
+func (parser *profileExpressionParser) parseAnd() (profileMatcher, error) {
+	left, err := parser.parseNot()
+	if err != nil {
+		return nil, err
+	}
+
+	for parser.peek() == "&" {
+		parser.position++
+		right, err := parser.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		left = andProfileMatcher(left, right)
+	}
+	return left, nil
+}
+
+func (parser *profileExpressionParser) parseNot() (profileMatcher, error) {
+	token := parser.peek()
+	switch token {
+	case "!":
+		parser.position++
+		matcher, err := parser.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return func(isActive func(profile string) bool) bool {
+			return !matcher(isActive)
+		}, nil
+	case "(":
+		parser.position++
+		matcher, err := parser.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if parser.peek() != ")" {
+			return nil, errors.New("missing ')' in profile expression : " + parser.expression)
+		}
+		parser.position++
+		return matcher, nil
+	case "", ")", "&", "|":
+		return nil, errors.New("profile name is expected in profile expression : " + parser.expression)
+	}
+
+	parser.position++
+	return func(isActive func(profile string) bool) bool {
+		return isActive(token)
+	}, nil
+}
+
+func andProfileMatcher(left profileMatcher, right profileMatcher) profileMatcher {
+	return func(isActive func(profile string) bool) bool {
+		return left(isActive) && right(isActive)
+	}
+}
+
+func orProfileMatcher(left profileMatcher, right profileMatcher) profileMatcher {
+	return func(isActive func(profile string) bool) bool {
+		return left(isActive) || right(isActive)
+	}
+}
+
+func tokenizeProfileExpression(expression string) []string {
+	tokens := make([]string, 0)
+	start := -1
+	for index, char := range expression {
+		if strings.ContainsRune("!&|() \t", char) {
+			if start != -1 {
+				tokens = append(tokens, expression[start:index])
+				start = -1
+			}
+			if char != ' ' && char != '\t' {
+				tokens = append(tokens, string(char))
+			}
+		} else if start == -1 {
+			start = index
+		}
+	}
+
+	if start != -1 {
+		tokens = append(tokens, expression[start:])
+	}
+	return tokens
+}
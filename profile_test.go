@@ -0,0 +1,153 @@
+package peas
+
+import (
+	"github.com/procyon-projects/goo"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"testing"
+)
+
+func TestParseProfileExpression(t *testing.T) {
+	isActive := func(profile string) bool {
+		return profile == "local" || profile == "cloud"
+	}
+
+	expressions := map[string]bool{
+		"local":                   true,
+		"prod":                    false,
+		"!prod":                   true,
+		"!local":                  false,
+		"local & cloud":           true,
+		"local & prod":            false,
+		"prod | cloud":            true,
+		"prod | test":             false,
+		"prod | local & cloud":    true,
+		"(prod | local) & !cloud": false,
+		"!(prod|test)":            true,
+		"!!local":                 true,
+	}
+
+	for expression, expected := range expressions {
+		matcher, err := parseProfileExpression(expression)
+		assert.Nil(t, err, expression)
+		assert.Equal(t, expected, matcher(isActive), expression)
+	}
+
+	invalidExpressions := map[string]string{
+		"":              "profile expression must not be empty",
+		"local &":       "profile name is expected in profile expression : local &",
+		"(local | prod": "missing ')' in profile expression : (local | prod",
+		"local prod":    "unexpected 'prod' in profile expression : local prod",
+		"| local":       "profile name is expected in profile expression : | local",
+	}
+
+	for expression, message := range invalidExpressions {
+		_, err := parseProfileExpression(expression)
+		assert.NotNil(t, err, expression)
+		assert.Equal(t, message, err.Error())
+	}
+}
+
+func TestDefaultPeaFactory_ActiveProfiles(t *testing.T) {
+	peaFactory := NewDefaultPeaFactory()
+	assert.Empty(t, peaFactory.GetActiveProfiles())
+	assert.Equal(t, []string{DefaultProfile}, peaFactory.GetDefaultProfiles())
+	assert.True(t, peaFactory.AcceptsProfiles("default"))
+
+	os.Setenv("PEAS_PROFILES_ACTIVE", "local, cloud")
+	defer os.Unsetenv("PEAS_PROFILES_ACTIVE")
+	assert.Equal(t, []string{"local", "cloud"}, peaFactory.GetActiveProfiles())
+	assert.True(t, peaFactory.AcceptsProfiles("local & cloud"))
+	assert.False(t, peaFactory.AcceptsProfiles("default"))
+
+	peaFactory.SetActiveProfiles("prod")
+	assert.Equal(t, []string{"prod"}, peaFactory.GetActiveProfiles())
+	assert.True(t, peaFactory.AcceptsProfiles("local", "prod"))
+	assert.False(t, peaFactory.AcceptsProfiles("local", "!prod", "prod &"))
+
+	peaFactory = NewDefaultPeaFactory(WithActiveProfiles(), WithDefaultProfiles("local"))
+	assert.Equal(t, []string{}, peaFactory.GetActiveProfiles())
+	assert.True(t, peaFactory.AcceptsProfiles("local"))
+}
+
+func TestDefaultPeaFactory_PeaDefinitionsWithProfiles(t *testing.T) {
+	queueType := goo.GetType((*messageQueue)(nil))
+
+	peaFactory := NewDefaultPeaFactory(WithActiveProfiles("local"))
+	peaFactory.RegisterPeaDefinition("inMemoryQueue", NewSimplePeaDefinition(goo.GetType(newInMemoryQueue), WithProfiles("local", "test")))
+	peaFactory.RegisterPeaDefinition("brokerQueue", NewSimplePeaDefinition(goo.GetType(newBrokerQueue), WithProfiles("!local & !test")))
+
+	assert.Equal(t, []string{"inMemoryQueue"}, peaFactory.GetPeaNamesByType(queueType))
+	assert.Equal(t, []string{"inMemoryQueue"}, peaFactory.GetPeaNamesForType(queueType, true))
+	assert.False(t, peaFactory.ContainsPea("brokerQueue"))
+
+	pea, err := peaFactory.GetPeaByType(queueType)
+	assert.Nil(t, err)
+	assert.IsType(t, &inMemoryQueue{}, pea)
+
+	_, err = peaFactory.GetPea("brokerQueue")
+	assert.NotNil(t, err)
+	assert.Equal(t, "pea definition is not active for the current profiles : brokerQueue", err.Error())
+
+	err = peaFactory.PreInstantiateSharedPeas()
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"inMemoryQueue"}, peaFactory.GetSharedPeaNames())
+
+	peaFactory = NewDefaultPeaFactory(WithActiveProfiles("prod"))
+	peaFactory.RegisterPeaDefinition("inMemoryQueue", NewSimplePeaDefinition(goo.GetType(newInMemoryQueue), WithProfiles("local", "test")))
+	peaFactory.RegisterPeaDefinition("brokerQueue", NewSimplePeaDefinition(goo.GetType(newBrokerQueue), WithProfiles("!local & !test")))
+
+	err = peaFactory.PreInstantiateSharedPeas()
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"brokerQueue"}, peaFactory.GetSharedPeaNames())
+}
+
+func TestDefaultPeaFactory_PeaDefinitionsWithProfilesInParentFactory(t *testing.T) {
+	parentFactory := NewDefaultPeaFactory()
+	parentFactory.RegisterPeaDefinition("queue", NewSimplePeaDefinition(goo.GetType(newBrokerQueue)))
+
+	peaFactory := NewChildPeaFactory(parentFactory, WithActiveProfiles("prod"))
+	peaFactory.RegisterPeaDefinition("queue", NewSimplePeaDefinition(goo.GetType(newInMemoryQueue), WithProfiles("local")))
+
+	pea, err := peaFactory.GetPea("queue")
+	assert.Nil(t, err)
+	assert.IsType(t, &brokerQueue{}, pea)
+}
+
+func TestDefaultPeaFactory_EvaluateConditionsSkipsInactivePeaDefinitions(t *testing.T) {
+	queueType := goo.GetType((*messageQueue)(nil))
+
+	peaFactory := NewDefaultPeaFactory()
+	peaFactory.RegisterPeaDefinition("brokerQueue", NewSimplePeaDefinition(goo.GetType(newBrokerQueue), WithProfiles("prod")))
+	peaFactory.RegisterPeaDefinition("inMemoryQueue", NewSimplePeaDefinition(goo.GetType(newInMemoryQueue),
+		WithConditionOnMissingPea(queueType)))
+
+	report := peaFactory.EvaluateConditions()
+	assert.Len(t, report.GetEvaluations(), 1)
+	assert.Equal(t, []string{"brokerQueue", "inMemoryQueue"}, peaFactory.GetPeaDefinitionNames())
+
+	pea, err := peaFactory.GetPeaByType(queueType)
+	assert.Nil(t, err)
+	assert.IsType(t, &inMemoryQueue{}, pea)
+}
+
+func TestDefaultPeaFactory_EvaluateConditionsOnMissingPeaNameSkipsInactivePeaDefinitions(t *testing.T) {
+	peaFactory := NewDefaultPeaFactory()
+	peaFactory.RegisterPeaDefinition("queue", NewSimplePeaDefinition(goo.GetType(newBrokerQueue), WithProfiles("prod")))
+	peaFactory.RegisterPeaDefinition("inMemoryQueue", NewSimplePeaDefinition(goo.GetType(newInMemoryQueue),
+		WithConditionOnMissingPeaName("queue")))
+	peaFactory.RegisterPeaDefinition("queueMonitor", NewSimplePeaDefinition(goo.GetType(newInMemoryQueue),
+		WithConditionOnPea("queue")))
+
+	peaFactory.EvaluateConditions()
+	assert.Equal(t, []string{"queue", "inMemoryQueue"}, peaFactory.GetPeaDefinitionNames())
+}
+
+func TestDefaultPeaDefinitionRegistry_RegisterPeaDefinitionWithInvalidProfiles(t *testing.T) {
+	peaFactory := NewDefaultPeaFactory(WithActiveProfiles("local"))
+
+	err := peaFactory.RegisterPeaDefinition("queue", NewSimplePeaDefinition(goo.GetType(newInMemoryQueue), WithProfiles("local &")))
+	assert.NotNil(t, err)
+	assert.Equal(t, "pea definition 'queue' could not be registered : profile name is expected in profile expression : local &", err.Error())
+	assert.False(t, peaFactory.ContainsPeaDefinition("queue"))
+}
@@ -3,6 +3,7 @@ package peas
 import (
 	"errors"
 	"github.com/procyon-projects/goo"
+	"strings"
 	"sync"
 )
 
@@ -10,53 +11,97 @@ const defaultSharedObjectsMapSize = 0
 
 type SharedPeaRegistry interface {
 	RegisterSharedPea(peaName string, sharedObject interface{}) error
+	RegisterSharedPeaAs(peaName string, sharedObject interface{}, exposedTypes ...goo.Type) error
 	GetSharedPea(peaName string) interface{}
+	RemoveSharedPea(peaName string)
 	ContainsSharedPea(peaName string) bool
 	GetSharedPeaNames() []string
 	GetSharedPeaCount() int
 	GetSharedPeaType(requiredType goo.Type) interface{}
 	GetSharedPeasByType(requiredType goo.Type) []interface{}
+	GetSharedPeaNamesByType(requiredType goo.Type) []string
 	GetSharedPeaWithObjectFunc(peaName string, objFunc GetObjectFunc) (interface{}, error)
 }
 
 type DefaultSharedPeaRegistry struct {
 	sharedObjects              map[string]interface{}
-	sharedObjectsInPreparation map[string]interface{}
+	sharedObjectNames          []string
+	sharedObjectsInPreparation map[string]*sharedPeaPreparation
+	preparationsWaitedFor      map[uint64]string
 	sharedObjectsType          map[string]goo.Type
+	sharedObjectsExposedTypes  map[string][]goo.Type
+	typeMatcher                TypeMatcher
 	muSharedObjects            sync.RWMutex
 }
 
 func NewDefaultSharedPeaRegistry() *DefaultSharedPeaRegistry {
 	return &DefaultSharedPeaRegistry{
 		sharedObjects:              make(map[string]interface{}, defaultSharedObjectsMapSize),
-		sharedObjectsInPreparation: make(map[string]interface{}, defaultSharedObjectsMapSize),
+		sharedObjectNames:          make([]string, 0),
+		sharedObjectsInPreparation: make(map[string]*sharedPeaPreparation, defaultSharedObjectsMapSize),
+		preparationsWaitedFor:      make(map[uint64]string),
 		sharedObjectsType:          make(map[string]goo.Type, defaultSharedObjectsMapSize),
+		sharedObjectsExposedTypes:  make(map[string][]goo.Type, defaultSharedObjectsMapSize),
+		typeMatcher:                NewDefaultTypeMatcher(),
 		muSharedObjects:            sync.RWMutex{},
 	}
 }
 
+func (registry *DefaultSharedPeaRegistry) SetTypeMatcher(matcher TypeMatcher) {
+	registry.muSharedObjects.Lock()
+	registry.typeMatcher = matcher
+	registry.muSharedObjects.Unlock()
+}
+
+func (registry *DefaultSharedPeaRegistry) GetTypeMatcher() TypeMatcher {
+	registry.muSharedObjects.RLock()
+	defer registry.muSharedObjects.RUnlock()
+	return registry.typeMatcher
+}
+
 func (registry *DefaultSharedPeaRegistry) RegisterSharedPea(peaName string, sharedObject interface{}) error {
+	return registry.RegisterSharedPeaAs(peaName, sharedObject)
+}
+
+func (registry *DefaultSharedPeaRegistry) RegisterSharedPeaAs(peaName string, sharedObject interface{}, exposedTypes ...goo.Type) error {
 	if peaName == "" || sharedObject == nil {
 		return errors.New("pea name or shared object must not be null or empty")
 	}
 
-	sharedObjectType := goo.GetType(sharedObject)
-	if !sharedObjectType.IsInterface() && !sharedObjectType.IsStruct() {
-		return errors.New("pea object must be only instance of struct")
+	sharedObjectType, err := getInstanceType(sharedObject)
+	if err != nil {
+		return err
 	}
 
 	registry.muSharedObjects.Lock()
+	for _, exposedType := range exposedTypes {
+		if exposedType == nil || !matchesInstanceType(registry.typeMatcher, sharedObjectType, exposedType) {
+			registry.muSharedObjects.Unlock()
+			return errors.New("pea object cannot be exposed as the given type : " + peaName)
+		}
+	}
+
 	if _, ok := registry.sharedObjects[peaName]; ok {
 		registry.muSharedObjects.Unlock()
 		return errors.New("could not register shared object with same name")
 	}
 
 	registry.sharedObjects[peaName] = sharedObject
+	registry.sharedObjectNames = append(registry.sharedObjectNames, peaName)
+	registry.sharedObjectsType[peaName] = sharedObjectType
+	if len(exposedTypes) != 0 {
+		registry.sharedObjectsExposedTypes[peaName] = exposedTypes
+	}
 	registry.muSharedObjects.Unlock()
-	registry.addInstanceSharedObjectsType(peaName, sharedObjectType)
 	return nil
 }
 
+func (registry *DefaultSharedPeaRegistry) GetSharedPeaExposedTypes(peaName string) []goo.Type {
+	registry.muSharedObjects.RLock()
+	defer registry.muSharedObjects.RUnlock()
+	return registry.sharedObjectsExposedTypes[peaName]
+}
+
 func (registry *DefaultSharedPeaRegistry) GetSharedPea(peaName string) interface{} {
 	defer func() {
 		registry.muSharedObjects.Unlock()
@@ -69,6 +114,17 @@ func (registry *DefaultSharedPeaRegistry) GetSharedPea(peaName string) interface
 	return result
 }
 
+func (registry *DefaultSharedPeaRegistry) RemoveSharedPea(peaName string) {
+	registry.muSharedObjects.Lock()
+	if _, ok := registry.sharedObjects[peaName]; ok {
+		delete(registry.sharedObjects, peaName)
+		delete(registry.sharedObjectsType, peaName)
+		delete(registry.sharedObjectsExposedTypes, peaName)
+		registry.sharedObjectNames = removeString(registry.sharedObjectNames, peaName)
+	}
+	registry.muSharedObjects.Unlock()
+}
+
 func (registry *DefaultSharedPeaRegistry) ContainsSharedPea(peaName string) bool {
 	defer func() {
 		registry.muSharedObjects.Unlock()
@@ -85,7 +141,7 @@ func (registry *DefaultSharedPeaRegistry) GetSharedPeaNames() []string {
 		registry.muSharedObjects.Unlock()
 	}()
 	registry.muSharedObjects.Lock()
-	names := getStringMapKeys(registry.sharedObjects)
+	names := append(make([]string, 0, len(registry.sharedObjectNames)), registry.sharedObjectNames...)
 	return names
 }
 
@@ -99,9 +155,10 @@ func (registry *DefaultSharedPeaRegistry) GetSharedPeaCount() int {
 }
 
 func (registry *DefaultSharedPeaRegistry) GetSharedPeaType(requiredType goo.Type) interface{} {
-	instances := registry.GetSharedPeasByType(requiredType)
+	peaNames, instances := registry.getSharedPeasByType(requiredType)
 	if len(instances) > 1 {
-		panic("Instances of required type cannot be distinguished")
+		panic("Instances of required type cannot be distinguished : " + requiredType.GetFullName() +
+			" (candidates : " + strings.Join(peaNames, ", ") + ")")
 	} else if len(instances) == 0 {
 		return nil
 	}
@@ -109,6 +166,30 @@ func (registry *DefaultSharedPeaRegistry) GetSharedPeaType(requiredType goo.Type
 }
 
 func (registry *DefaultSharedPeaRegistry) GetSharedPeasByType(requiredType goo.Type) []interface{} {
+	_, instances := registry.getSharedPeasByType(requiredType)
+	return instances
+}
+
+func (registry *DefaultSharedPeaRegistry) getSharedPeasByType(requiredType goo.Type) ([]string, []interface{}) {
+	peaNames := registry.GetSharedPeaNamesByType(requiredType)
+
+	defer func() {
+		registry.muSharedObjects.Unlock()
+	}()
+
+	names := make([]string, 0)
+	instances := make([]interface{}, 0)
+	registry.muSharedObjects.Lock()
+	for _, peaName := range peaNames {
+		if instance, ok := registry.sharedObjects[peaName]; ok {
+			names = append(names, peaName)
+			instances = append(instances, instance)
+		}
+	}
+	return names, instances
+}
+
+func (registry *DefaultSharedPeaRegistry) GetSharedPeaNamesByType(requiredType goo.Type) []string {
 	if requiredType == nil {
 		panic("Required type must not be nil")
 	}
@@ -117,64 +198,92 @@ func (registry *DefaultSharedPeaRegistry) GetSharedPeasByType(requiredType goo.T
 		registry.muSharedObjects.Unlock()
 	}()
 
-	instances := make([]interface{}, 0)
+	peaNames := make([]string, 0)
 	registry.muSharedObjects.Lock()
-	for peaName, peaType := range registry.sharedObjectsType {
-		match := false
-		if peaType.Equals(requiredType) {
-			match = true
-		} else if requiredType.IsInterface() && peaType.ToStructType().Implements(requiredType.ToInterfaceType()) {
-			match = true
-		} else if requiredType.IsStruct() && peaType.ToStructType().EmbeddedStruct(requiredType.ToStructType()) {
-			match = true
+	for _, peaName := range registry.sharedObjectNames {
+		peaType, ok := registry.sharedObjectsType[peaName]
+		if !ok {
+			continue
 		}
-		if match {
-			instances = append(instances, registry.sharedObjects[peaName])
+
+		if exposedTypes, ok := registry.sharedObjectsExposedTypes[peaName]; ok {
+			if matchesAnyType(registry.typeMatcher, exposedTypes, requiredType) {
+				peaNames = append(peaNames, peaName)
+			}
+		} else if matchesInstanceType(registry.typeMatcher, peaType, requiredType) {
+			peaNames = append(peaNames, peaName)
 		}
 	}
-	return instances
+	return peaNames
+}
+
+type sharedPeaPreparation struct {
+	goroutineID uint64
+	done        chan struct{}
 }
 
+// GetSharedPeaWithObjectFunc creates the shared pea by using objFunc unless it exists. If the pea is already being
+// created by another goroutine, it waits for that creation instead. It returns PeaInPreparationError only if the pea
+// is requested again while it is being created by the same goroutine, or the goroutines would wait for each other.
 func (registry *DefaultSharedPeaRegistry) GetSharedPeaWithObjectFunc(peaName string, objFunc GetObjectFunc) (interface{}, error) {
-	sharedPea := registry.GetSharedPea(peaName)
-	if sharedPea != nil {
-		return sharedPea, nil
-	}
+	goroutineID := getGoroutineID()
+	for {
+		registry.muSharedObjects.Lock()
+		if sharedPea, ok := registry.sharedObjects[peaName]; ok {
+			registry.muSharedObjects.Unlock()
+			return sharedPea, nil
+		}
 
-	err := registry.addSharedPeaToPreparation(peaName)
-	if err != nil {
-		return nil, err
+		preparation, ok := registry.sharedObjectsInPreparation[peaName]
+		if !ok {
+			break
+		}
+
+		if registry.isWaitingFor(preparation, goroutineID) {
+			registry.muSharedObjects.Unlock()
+			return nil, NewPeaInPreparationError(peaName)
+		}
+
+		registry.preparationsWaitedFor[goroutineID] = peaName
+		registry.muSharedObjects.Unlock()
+
+		<-preparation.done
+
+		registry.muSharedObjects.Lock()
+		delete(registry.preparationsWaitedFor, goroutineID)
+		registry.muSharedObjects.Unlock()
 	}
 
-	defer func() {
-		registry.removedSharedPeaFromPreparation(peaName)
-	}()
-	var newSharedObj interface{}
-	newSharedObj, err = objFunc()
-	registry.removedSharedPeaFromPreparation(peaName)
-	return newSharedObj, err
-}
+	preparation := &sharedPeaPreparation{goroutineID, make(chan struct{})}
+	registry.sharedObjectsInPreparation[peaName] = preparation
+	registry.muSharedObjects.Unlock()
 
-func (registry *DefaultSharedPeaRegistry) addSharedPeaToPreparation(peaName string) error {
 	defer func() {
+		registry.muSharedObjects.Lock()
+		delete(registry.sharedObjectsInPreparation, peaName)
 		registry.muSharedObjects.Unlock()
+		close(preparation.done)
 	}()
-	registry.muSharedObjects.Lock()
-	if _, ok := registry.sharedObjectsInPreparation[peaName]; ok {
-		return NewPeaInPreparationError(peaName)
-	}
-	registry.sharedObjectsInPreparation[peaName] = nil
-	return nil
-}
 
-func (registry *DefaultSharedPeaRegistry) removedSharedPeaFromPreparation(peaName string) {
-	registry.muSharedObjects.Lock()
-	delete(registry.sharedObjectsInPreparation, peaName)
-	registry.muSharedObjects.Unlock()
+	return objFunc()
 }
 
-func (registry *DefaultSharedPeaRegistry) addInstanceSharedObjectsType(peaName string, typ goo.Type) {
-	registry.muSharedObjects.Lock()
-	registry.sharedObjectsType[peaName] = typ
-	registry.muSharedObjects.Unlock()
+// isWaitingFor follows the preparations which the goroutines preparing the peas wait for, and returns true
+// if it reaches the given goroutine. The lock must be held by the caller.
+func (registry *DefaultSharedPeaRegistry) isWaitingFor(preparation *sharedPeaPreparation, goroutineID uint64) bool {
+	for index := 0; index <= len(registry.preparationsWaitedFor); index++ {
+		if preparation.goroutineID == goroutineID {
+			return true
+		}
+
+		peaName, ok := registry.preparationsWaitedFor[preparation.goroutineID]
+		if !ok {
+			return false
+		}
+
+		if preparation, ok = registry.sharedObjectsInPreparation[peaName]; !ok {
+			return false
+		}
+	}
+	return false
 }
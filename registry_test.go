@@ -4,29 +4,41 @@ import (
 	"github.com/procyon-projects/goo"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"net/http"
+	"sync/atomic"
 	"testing"
+	"time"
 )
 
 type sharedPeaRegistryMock struct {
 	mock.Mock
 }
 
-func (registry sharedPeaRegistryMock) RegisterSharedPea(peaName string, sharedObject interface{}) error {
+func (registry *sharedPeaRegistryMock) RegisterSharedPea(peaName string, sharedObject interface{}) error {
 	results := registry.Called(peaName, sharedObject)
 	return results.Error(0)
 }
 
-func (registry sharedPeaRegistryMock) GetSharedPea(peaName string) interface{} {
+func (registry *sharedPeaRegistryMock) RegisterSharedPeaAs(peaName string, sharedObject interface{}, exposedTypes ...goo.Type) error {
+	results := registry.Called(peaName, sharedObject, exposedTypes)
+	return results.Error(0)
+}
+
+func (registry *sharedPeaRegistryMock) GetSharedPea(peaName string) interface{} {
 	results := registry.Called(peaName)
 	return results.Get(0)
 }
 
-func (registry sharedPeaRegistryMock) ContainsSharedPea(peaName string) bool {
+func (registry *sharedPeaRegistryMock) RemoveSharedPea(peaName string) {
+	registry.Called(peaName)
+}
+
+func (registry *sharedPeaRegistryMock) ContainsSharedPea(peaName string) bool {
 	results := registry.Called(peaName)
 	return results.Bool(0)
 }
 
-func (registry sharedPeaRegistryMock) GetSharedPeaNames() []string {
+func (registry *sharedPeaRegistryMock) GetSharedPeaNames() []string {
 	results := registry.Called()
 	if results == nil {
 		return nil
@@ -34,17 +46,17 @@ func (registry sharedPeaRegistryMock) GetSharedPeaNames() []string {
 	return results.Get(0).([]string)
 }
 
-func (registry sharedPeaRegistryMock) GetSharedPeaCount() int {
+func (registry *sharedPeaRegistryMock) GetSharedPeaCount() int {
 	results := registry.Called()
 	return results.Int(0)
 }
 
-func (registry sharedPeaRegistryMock) GetSharedPeaType(requiredType goo.Type) interface{} {
+func (registry *sharedPeaRegistryMock) GetSharedPeaType(requiredType goo.Type) interface{} {
 	results := registry.Called(requiredType)
 	return results.Get(0)
 }
 
-func (registry sharedPeaRegistryMock) GetSharedPeasByType(requiredType goo.Type) []interface{} {
+func (registry *sharedPeaRegistryMock) GetSharedPeasByType(requiredType goo.Type) []interface{} {
 	results := registry.Called(requiredType)
 	if results == nil {
 		return nil
@@ -52,7 +64,15 @@ func (registry sharedPeaRegistryMock) GetSharedPeasByType(requiredType goo.Type)
 	return results.Get(0).([]interface{})
 }
 
-func (registry sharedPeaRegistryMock) GetSharedPeaWithObjectFunc(peaName string, objFunc GetObjectFunc) (interface{}, error) {
+func (registry *sharedPeaRegistryMock) GetSharedPeaNamesByType(requiredType goo.Type) []string {
+	results := registry.Called(requiredType)
+	if results == nil {
+		return nil
+	}
+	return results.Get(0).([]string)
+}
+
+func (registry *sharedPeaRegistryMock) GetSharedPeaWithObjectFunc(peaName string, objFunc GetObjectFunc) (interface{}, error) {
 	results := registry.Called(peaName, objFunc)
 	return results.Get(0), results.Error(1)
 }
@@ -72,12 +92,26 @@ func TestDefaultSharedPeaRegistry_RegisterSharedPeaWithEmptyPeaNameOrNilInstance
 func TestDefaultSharedPeaRegistry_RegisterSharedPeaWithNonInterfaceOrNonStruct(t *testing.T) {
 	peaRegistry := NewDefaultSharedPeaRegistry()
 	err := peaRegistry.RegisterSharedPea("test", "test-instance")
-	assert.NotNil(t, err)
-	assert.Equal(t, err.Error(), "pea object must be only instance of struct")
+	assert.Nil(t, err)
 
-	instance := testStruct{}
-	err = peaRegistry.RegisterSharedPea("test", instance)
+	err = peaRegistry.RegisterSharedPea("allowlist", []string{"localhost"})
+	assert.Nil(t, err)
+
+	err = peaRegistry.RegisterSharedPea("handler", http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
 	assert.Nil(t, err)
+
+	err = peaRegistry.RegisterSharedPea("timeout", 5*time.Second)
+	assert.Nil(t, err)
+
+	err = peaRegistry.RegisterSharedPea("channel", make(chan int))
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "pea object type is not supported")
+
+	assert.Equal(t, []string{"test"}, peaRegistry.GetSharedPeaNamesByType(goo.GetType("")))
+	assert.Equal(t, []string{"allowlist"}, peaRegistry.GetSharedPeaNamesByType(goo.GetType([]string{})))
+	assert.Equal(t, []string{"handler"}, peaRegistry.GetSharedPeaNamesByType(goo.GetType(http.HandlerFunc(nil))))
+	assert.Equal(t, []string{"timeout"}, peaRegistry.GetSharedPeaNamesByType(goo.GetType(time.Duration(0))))
+	assert.Empty(t, peaRegistry.GetSharedPeaNamesByType(goo.GetType(int64(0))))
 }
 
 func TestDefaultSharedPeaRegistry_RegisterSharedPeaWithSamePeaName(t *testing.T) {
@@ -119,6 +153,18 @@ func TestDefaultSharedPeaRegistry_GetSharedPea(t *testing.T) {
 	assert.Nil(t, peaRegistry.GetSharedPea("test2"))
 }
 
+func TestDefaultSharedPeaRegistry_RemoveSharedPea(t *testing.T) {
+	peaRegistry := NewDefaultSharedPeaRegistry()
+
+	err := peaRegistry.RegisterSharedPea("test1", testStruct{})
+	assert.Nil(t, err)
+	peaRegistry.RemoveSharedPea("test1")
+	peaRegistry.RemoveSharedPea("test2")
+	assert.Nil(t, peaRegistry.GetSharedPea("test1"))
+	assert.Equal(t, 0, peaRegistry.GetSharedPeaCount())
+	assert.Empty(t, peaRegistry.GetSharedPeaNamesByType(goo.GetType(testStruct{})))
+}
+
 func TestDefaultSharedPeaRegistry_ContainsSharedPea(t *testing.T) {
 	peaRegistry := NewDefaultSharedPeaRegistry()
 
@@ -145,6 +191,21 @@ func TestDefaultSharedPeaRegistry_GetSharedPeaNames(t *testing.T) {
 	assert.Contains(t, sharedPeaNames, "test2")
 }
 
+func TestDefaultSharedPeaRegistry_GetSharedPeaNamesInRegistrationOrder(t *testing.T) {
+	peaRegistry := NewDefaultSharedPeaRegistry()
+
+	peaNames := []string{"test5", "test1", "test4", "test2", "test3"}
+	for _, peaName := range peaNames {
+		err := peaRegistry.RegisterSharedPea(peaName, testStruct{})
+		assert.Nil(t, err)
+	}
+	assert.Equal(t, peaNames, peaRegistry.GetSharedPeaNames())
+	assert.Equal(t, peaNames, peaRegistry.GetSharedPeaNamesByType(goo.GetType(testStruct{})))
+
+	peaRegistry.RemoveSharedPea("test4")
+	assert.Equal(t, []string{"test5", "test1", "test2", "test3"}, peaRegistry.GetSharedPeaNames())
+}
+
 func TestDefaultSharedPeaRegistry_GetSharedPeaCount(t *testing.T) {
 	peaRegistry := NewDefaultSharedPeaRegistry()
 
@@ -185,6 +246,28 @@ func TestDefaultSharedPeaRegistry_GetSharedPeasByType(t *testing.T) {
 	assert.Equal(t, 2, len(peas))
 }
 
+func TestDefaultSharedPeaRegistry_GetSharedPeaNamesByType(t *testing.T) {
+	peaRegistry := NewDefaultSharedPeaRegistry()
+
+	err := peaRegistry.RegisterSharedPea("test1", testStruct{})
+	assert.Nil(t, err)
+	err = peaRegistry.RegisterSharedPea("test2", testStruct2{})
+	assert.Nil(t, err)
+
+	peaNames := peaRegistry.GetSharedPeaNamesByType(goo.GetType(testStruct{}))
+	assert.Equal(t, []string{"test1"}, peaNames)
+
+	peaNames = peaRegistry.GetSharedPeaNamesByType(goo.GetType((*testInterface)(nil)))
+	assert.Equal(t, []string{"test1"}, peaNames)
+
+	peaNames = peaRegistry.GetSharedPeaNamesByType(goo.GetType(testStruct2{}))
+	assert.Equal(t, []string{"test2"}, peaNames)
+
+	assert.Panics(t, func() {
+		peaRegistry.GetSharedPeaNamesByType(nil)
+	})
+}
+
 func TestDefaultSharedPeaRegistry_GetSharedPeaType(t *testing.T) {
 	peaRegistry := NewDefaultSharedPeaRegistry()
 
@@ -215,7 +298,8 @@ func TestDefaultSharedPeaRegistry_GetSharedPeaTypeForInstancesDistinguished(t *t
 	err = peaRegistry.RegisterSharedPea("test2", instance2)
 	assert.Nil(t, err)
 
-	assert.Panics(t, func() {
+	assert.PanicsWithValue(t, "Instances of required type cannot be distinguished : "+goo.GetType(testStruct{}).GetFullName()+
+		" (candidates : test1, test2)", func() {
 		peaRegistry.GetSharedPeaType(goo.GetType(testStruct{}))
 	})
 
@@ -237,3 +321,123 @@ func TestDefaultSharedPeaRegistry_GetSharedPeaWithObjectFunc(t *testing.T) {
 	assert.NotNil(t, pea)
 	assert.Nil(t, err)
 }
+
+func TestDefaultSharedPeaRegistry_GetSharedPeaWithObjectFuncConcurrently(t *testing.T) {
+	peaRegistry := NewDefaultSharedPeaRegistry()
+	started := make(chan struct{})
+	release := make(chan struct{})
+	var creationCount int32
+
+	objFunc := func() (interface{}, error) {
+		if atomic.AddInt32(&creationCount, 1) == 1 {
+			close(started)
+		}
+		<-release
+		instance := &testStruct{}
+		return instance, peaRegistry.RegisterSharedPea("test", instance)
+	}
+
+	results := make(chan interface{}, 2)
+	errs := make(chan error, 2)
+	lookup := func() {
+		pea, err := peaRegistry.GetSharedPeaWithObjectFunc("test", objFunc)
+		results <- pea
+		errs <- err
+	}
+
+	go lookup()
+	<-started
+	go lookup()
+
+	for !peaRegistry.isPreparationWaitedFor("test") {
+		time.Sleep(time.Millisecond)
+	}
+	close(release)
+
+	assert.Nil(t, <-errs)
+	assert.Nil(t, <-errs)
+	assert.Same(t, <-results, <-results)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&creationCount))
+}
+
+func TestDefaultSharedPeaRegistry_GetSharedPeaWithObjectFuncReentered(t *testing.T) {
+	peaRegistry := NewDefaultSharedPeaRegistry()
+
+	_, err := peaRegistry.GetSharedPeaWithObjectFunc("test", func() (interface{}, error) {
+		return peaRegistry.GetSharedPeaWithObjectFunc("test", func() (interface{}, error) {
+			return testStruct{}, nil
+		})
+	})
+	assert.Equal(t, NewPeaInPreparationError("test"), err)
+}
+
+func TestDefaultSharedPeaRegistry_GetSharedPeaWithObjectFuncWaitingForEachOther(t *testing.T) {
+	peaRegistry := NewDefaultSharedPeaRegistry()
+	started := make(chan struct{})
+
+	errs := make(chan error, 1)
+	go func() {
+		_, err := peaRegistry.GetSharedPeaWithObjectFunc("first", func() (interface{}, error) {
+			close(started)
+			for !peaRegistry.isPreparationWaitedFor("first") {
+				time.Sleep(time.Millisecond)
+			}
+			return peaRegistry.GetSharedPeaWithObjectFunc("second", func() (interface{}, error) {
+				return testStruct{}, nil
+			})
+		})
+		errs <- err
+	}()
+
+	<-started
+	_, err := peaRegistry.GetSharedPeaWithObjectFunc("second", func() (interface{}, error) {
+		return peaRegistry.GetSharedPeaWithObjectFunc("first", func() (interface{}, error) {
+			return testStruct{}, nil
+		})
+	})
+
+	assert.Equal(t, NewPeaInPreparationError("second"), <-errs)
+	assert.Nil(t, err)
+}
+
+func (registry *DefaultSharedPeaRegistry) isPreparationWaitedFor(peaName string) bool {
+	registry.muSharedObjects.Lock()
+	defer registry.muSharedObjects.Unlock()
+	for _, waitedPeaName := range registry.preparationsWaitedFor {
+		if waitedPeaName == peaName {
+			return true
+		}
+	}
+	return false
+}
+
+type postgresStore struct {
+}
+
+func (store *postgresStore) testMethod() {
+
+}
+
+func (store *postgresStore) InitializePea() error {
+	return nil
+}
+
+func TestDefaultSharedPeaRegistry_RegisterSharedPeaAs(t *testing.T) {
+	peaRegistry := NewDefaultSharedPeaRegistry()
+	testInterfaceType := goo.GetType((*testInterface)(nil))
+	err := peaRegistry.RegisterSharedPeaAs("store", &postgresStore{}, testInterfaceType)
+	assert.Nil(t, err)
+	assert.Equal(t, []goo.Type{testInterfaceType}, peaRegistry.GetSharedPeaExposedTypes("store"))
+
+	assert.Equal(t, []string{"store"}, peaRegistry.GetSharedPeaNamesByType(testInterfaceType))
+	assert.Empty(t, peaRegistry.GetSharedPeaNamesByType(goo.GetType(postgresStore{})))
+	assert.Empty(t, peaRegistry.GetSharedPeaNamesByType(goo.GetType((*PeaInitializer)(nil))))
+
+	err = peaRegistry.RegisterSharedPeaAs("anotherStore", &postgresStore{}, goo.GetType(testStruct{}))
+	assert.NotNil(t, err)
+	assert.Equal(t, "pea object cannot be exposed as the given type : anotherStore", err.Error())
+	assert.False(t, peaRegistry.ContainsSharedPea("anotherStore"))
+
+	peaRegistry.RemoveSharedPea("store")
+	assert.Nil(t, peaRegistry.GetSharedPeaExposedTypes("store"))
+}
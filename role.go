@@ -0,0 +1,8 @@
+package peas
+
+type PeaRole string
+
+const (
+	ApplicationRole    PeaRole = "application"
+	InfrastructureRole PeaRole = "infrastructure"
+)
@@ -0,0 +1,189 @@
+package peas
+
+import (
+	"errors"
+	"strings"
+	"sync"
+)
+
+type mergedPeaDefinition struct {
+	chain      []PeaDefinition
+	definition PeaDefinition
+}
+
+type mergedPeaDefinitionCache struct {
+	definitions map[string]*mergedPeaDefinition
+	mu          sync.RWMutex
+}
+
+func newMergedPeaDefinitionCache() *mergedPeaDefinitionCache {
+	return &mergedPeaDefinitionCache{
+		definitions: make(map[string]*mergedPeaDefinition, 0),
+		mu:          sync.RWMutex{},
+	}
+}
+
+func (cache *mergedPeaDefinitionCache) get(peaName string, chain []PeaDefinition) PeaDefinition {
+	cache.mu.RLock()
+	defer cache.mu.RUnlock()
+
+	merged, ok := cache.definitions[peaName]
+	if !ok || len(merged.chain) != len(chain) {
+		return nil
+	}
+
+	for index, definition := range chain {
+		if merged.chain[index] != definition {
+			return nil
+		}
+	}
+	return merged.definition
+}
+
+func (cache *mergedPeaDefinitionCache) put(peaName string, chain []PeaDefinition, definition PeaDefinition) {
+	cache.mu.Lock()
+	cache.definitions[peaName] = &mergedPeaDefinition{chain, definition}
+	cache.mu.Unlock()
+}
+
+func (factory DefaultPeaFactory) GetMergedPeaDefinition(peaName string) (PeaDefinition, error) {
+	peaName = factory.CanonicalName(peaName)
+	chain, err := getPeaDefinitionChain(peaName, factory.PeaDefinitionRegistry.GetPeaDefinition)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(chain) == 1 {
+		return chain[0], nil
+	}
+
+	if definition := factory.mergedDefinitions.get(peaName, chain); definition != nil {
+		return definition, nil
+	}
+
+	definition := mergePeaDefinitionChain(chain)
+	factory.mergedDefinitions.put(peaName, chain, definition)
+	return definition, nil
+}
+
+func (factory DefaultPeaFactory) getMergedPeaDefinition(peaName string) PeaDefinition {
+	definition, err := factory.GetMergedPeaDefinition(peaName)
+	if err != nil {
+		return nil
+	}
+	return definition
+}
+
+func getPeaDefinitionChain(peaName string, getPeaDefinition func(peaName string) PeaDefinition) ([]PeaDefinition, error) {
+	definition := getPeaDefinition(peaName)
+	if definition == nil {
+		return nil, errors.New("pea definition couldn't be found : " + peaName)
+	}
+
+	chain := []PeaDefinition{definition}
+	peaNames := []string{peaName}
+	for definition.GetParentName() != "" {
+		parentName := definition.GetParentName()
+		for _, name := range peaNames {
+			if name == parentName {
+				return nil, NewPeaPreparationError(peaName, "circular parent definition : "+strings.Join(append(peaNames, parentName), " -> "))
+			}
+		}
+
+		definition = getPeaDefinition(parentName)
+		if definition == nil {
+			return nil, NewPeaPreparationError(peaName, "parent pea definition couldn't be found : "+parentName)
+		}
+
+		chain = append(chain, definition)
+		peaNames = append(peaNames, parentName)
+	}
+	return chain, nil
+}
+
+func mergePeaDefinitionChain(chain []PeaDefinition) PeaDefinition {
+	merged := &SimplePeaDefinition{
+		arguments:  make(map[int]interface{}, 0),
+		attributes: make(map[string]interface{}, 0),
+		tags:       make([]string, 0),
+	}
+
+	for index := len(chain) - 1; index >= 0; index-- {
+		mergePeaDefinition(merged, chain[index])
+	}
+
+	merged.abstract = chain[0].IsAbstract()
+	merged.primary = chain[0].IsPrimary()
+	merged.parentName = ""
+	merged.source = chain[0].GetSource()
+	return merged
+}
+
+func mergePeaDefinition(merged *SimplePeaDefinition, definition PeaDefinition) {
+	if definition.GetPeaType() != nil {
+		merged.typ = definition.GetPeaType()
+	}
+
+	if simpleDefinition, ok := definition.(*SimplePeaDefinition); ok {
+		if simpleDefinition.scope != "" {
+			merged.scope = simpleDefinition.scope
+		}
+		if simpleDefinition.lazyInit != nil {
+			merged.lazyInit = simpleDefinition.lazyInit
+		}
+		if simpleDefinition.autowireCandidate != nil {
+			merged.autowireCandidate = simpleDefinition.autowireCandidate
+		}
+	} else {
+		scope := definition.GetScope()
+		lazyInit := definition.IsLazyInit()
+		autowireCandidate := definition.IsAutowireCandidate()
+		merged.scope = scope
+		merged.lazyInit = &lazyInit
+		merged.autowireCandidate = &autowireCandidate
+	}
+
+	if definition.GetInitializationTimeout() != 0 {
+		merged.initializationTimeout = definition.GetInitializationTimeout()
+	}
+
+	if len(definition.GetExposedTypes()) != 0 {
+		merged.exposedTypes = definition.GetExposedTypes()
+	}
+
+	if len(definition.GetDependsOn()) != 0 {
+		merged.dependsOn = definition.GetDependsOn()
+	}
+
+	if len(definition.GetProfiles()) != 0 {
+		merged.profiles = definition.GetProfiles()
+	}
+
+	if definition.GetInitMethod() != "" {
+		merged.initMethod = definition.GetInitMethod()
+	}
+
+	if definition.GetDestroyMethod() != "" {
+		merged.destroyMethod = definition.GetDestroyMethod()
+	}
+
+	for index, argument := range definition.GetArguments() {
+		merged.arguments[index] = argument
+	}
+
+	for key, value := range definition.GetAttributes() {
+		merged.attributes[key] = value
+	}
+
+	WithTags(definition.GetTags()...)(merged)
+
+	merged.conditions = append(merged.conditions, definition.GetConditions()...)
+
+	if definition.GetDescription() != "" {
+		merged.description = definition.GetDescription()
+	}
+
+	if simpleDefinition, ok := definition.(*SimplePeaDefinition); !ok || simpleDefinition.role != "" {
+		merged.role = definition.GetRole()
+	}
+}
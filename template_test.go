@@ -0,0 +1,105 @@
+package peas
+
+import (
+	"github.com/procyon-projects/goo"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+type httpClientPea struct {
+	baseUrl string
+	timeout time.Duration
+	events  *lifecycleEvents
+}
+
+func newHttpClientPea(baseUrl string, timeout time.Duration, events *lifecycleEvents) *httpClientPea {
+	return &httpClientPea{baseUrl, timeout, events}
+}
+
+func (client *httpClientPea) Open() {
+	client.events.add("open:" + client.baseUrl)
+}
+
+func TestDefaultPeaFactory_GetMergedPeaDefinition(t *testing.T) {
+	events := &lifecycleEvents{}
+	peaFactory := NewDefaultPeaFactory()
+	peaFactory.RegisterPeaDefinition("httpClientTemplate", NewSimplePeaDefinition(goo.GetType(newHttpClientPea),
+		WithAbstract(),
+		WithScope(PrototypeScope),
+		WithInitMethod("Open"),
+		WithArgument(0, "http://localhost"),
+		WithArgument(1, 5*time.Second),
+		WithArgument(2, events)))
+	peaFactory.RegisterPeaDefinition("userClient", NewSimplePeaDefinition(nil,
+		WithParent("httpClientTemplate"),
+		WithArgument(0, "http://users")))
+	peaFactory.RegisterPeaDefinition("orderClient", NewSimplePeaDefinition(nil,
+		WithParent("httpClientTemplate"),
+		WithScope(SharedScope),
+		WithPrimary(),
+		WithArgument(0, "http://orders")))
+
+	definition, err := peaFactory.GetMergedPeaDefinition("userClient")
+	assert.Nil(t, err)
+	assert.False(t, definition.IsAbstract())
+	assert.False(t, definition.IsPrimary())
+	assert.Equal(t, PrototypeScope, definition.GetScope())
+	assert.Equal(t, "Open", definition.GetInitMethod())
+	assert.Equal(t, "http://users", definition.GetArguments()[0])
+	assert.Equal(t, 5*time.Second, definition.GetArguments()[1])
+
+	cachedDefinition, err := peaFactory.GetMergedPeaDefinition("userClient")
+	assert.Nil(t, err)
+	assert.True(t, definition == cachedDefinition)
+
+	_, err = peaFactory.GetPea("httpClientTemplate")
+	assert.NotNil(t, err)
+	assert.Equal(t, "abstract pea definition cannot be instantiated : httpClientTemplate", err.Error())
+
+	err = peaFactory.PreInstantiateSharedPeas()
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"orderClient"}, peaFactory.GetSharedPeaNames())
+
+	pea, err := peaFactory.GetPea("userClient")
+	assert.Nil(t, err)
+	assert.Equal(t, "http://users", pea.(*httpClientPea).baseUrl)
+	assert.Equal(t, 5*time.Second, pea.(*httpClientPea).timeout)
+	assert.Equal(t, []string{"open:http://orders", "open:http://users"}, events.events)
+
+	assert.Equal(t, []string{"userClient", "orderClient"}, peaFactory.GetPeaNamesByType(goo.GetType(&httpClientPea{})))
+
+	pea, err = peaFactory.GetPeaByType(goo.GetType(&httpClientPea{}))
+	assert.Nil(t, err)
+	assert.Equal(t, "http://orders", pea.(*httpClientPea).baseUrl)
+}
+
+func TestDefaultPeaFactory_GetMergedPeaDefinitionWhenParentChanges(t *testing.T) {
+	peaFactory := NewDefaultPeaFactory()
+	peaFactory.RegisterPeaDefinition("template", NewSimplePeaDefinition(goo.GetType(newAStruct), WithAbstract()))
+	peaFactory.RegisterPeaDefinition("child", NewSimplePeaDefinition(nil, WithParent("template")))
+
+	definition, err := peaFactory.GetMergedPeaDefinition("child")
+	assert.Nil(t, err)
+	assert.Equal(t, SharedScope, definition.GetScope())
+
+	peaFactory.RegisterPeaDefinition("template", NewSimplePeaDefinition(goo.GetType(newAStruct), WithAbstract(), WithScope(PrototypeScope)))
+	definition, err = peaFactory.GetMergedPeaDefinition("child")
+	assert.Nil(t, err)
+	assert.Equal(t, PrototypeScope, definition.GetScope())
+
+	peaFactory.RemovePeaDefinition("template")
+	_, err = peaFactory.GetMergedPeaDefinition("child")
+	assert.NotNil(t, err)
+	assert.Equal(t, "child : parent pea definition couldn't be found : template", err.Error())
+}
+
+func TestDefaultPeaFactory_GetMergedPeaDefinitionWithCircularParents(t *testing.T) {
+	peaFactory := NewDefaultPeaFactory()
+	peaFactory.RegisterPeaDefinition("aPea", NewSimplePeaDefinition(goo.GetType(newAStruct), WithParent("bPea")))
+	peaFactory.RegisterPeaDefinition("bPea", NewSimplePeaDefinition(nil, WithParent("aPea")))
+
+	_, err := peaFactory.GetPea("aPea")
+	assert.NotNil(t, err)
+	assert.Equal(t, "aPea : circular parent definition : aPea -> bPea -> aPea", err.Error())
+}
@@ -1,8 +1,14 @@
 package peas
 
 import (
+	"context"
 	"errors"
 	"github.com/procyon-projects/goo"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strconv"
+	"strings"
 )
 
 func CreateInstance(typ goo.Type, args []interface{}) (interface{}, error) {
@@ -22,6 +28,53 @@ func CreateInstance(typ goo.Type, args []interface{}) (interface{}, error) {
 	return nil, errors.New("you can only pass Struct or Func types")
 }
 
+// createInstance supports the constructor functions returning an error as well, and returns the error they return.
+func createInstance(typ goo.Type, args []interface{}) (interface{}, error) {
+	if typ.IsFunction() && typ.ToFunctionType().GetFunctionReturnTypeCount() == 2 {
+		fun := typ.ToFunctionType()
+		if _, ok := getConstructorReturnType(fun); ok {
+			results := fun.Call(args)
+			if results[1] != nil {
+				return nil, results[1].(error)
+			}
+			return results[0], nil
+		}
+	}
+	return CreateInstance(typ, args)
+}
+
+// getConstructorReturnType returns the type of the instance which the constructor function creates. The constructor
+// functions can return either only the instance or the instance and an error.
+func getConstructorReturnType(fun goo.Function) (goo.Type, bool) {
+	returnTypes := fun.GetFunctionReturnTypes()
+	switch {
+	case len(returnTypes) == 1:
+		return returnTypes[0], true
+	case len(returnTypes) == 2 && getActualGoType(returnTypes[1]) == errorType:
+		return returnTypes[0], true
+	}
+	return nil, false
+}
+
+// checkArgumentType returns an error if the argument cannot be passed for the parameter, since the constructor
+// call would panic otherwise. nil is accepted only for the types which can be nil.
+func checkArgumentType(index int, argument interface{}, parameterType goo.Type) error {
+	parameterGoType := getActualGoType(parameterType)
+	if argument == nil {
+		switch parameterGoType.Kind() {
+		case reflect.Ptr, reflect.Interface, reflect.Map, reflect.Slice, reflect.Func, reflect.Chan:
+			return nil
+		}
+		return errors.New("argument " + strconv.Itoa(index) + " cannot be nil for parameter of type " + parameterGoType.String())
+	}
+
+	if argumentType := reflect.TypeOf(argument); !argumentType.AssignableTo(parameterGoType) {
+		return errors.New("argument " + strconv.Itoa(index) + " of type " + argumentType.String() +
+			" is not assignable to parameter of type " + parameterGoType.String())
+	}
+	return nil
+}
+
 func getStringMapKeys(mapObj interface{}) []string {
 	if mapObj == nil {
 		return nil
@@ -41,3 +94,88 @@ func getStringMapKeys(mapObj interface{}) []string {
 	}
 	return mapKeys
 }
+
+var packageDirectory = getPackageDirectory()
+
+func getPackageDirectory() string {
+	_, file, _, ok := runtime.Caller(0)
+	if !ok {
+		return ""
+	}
+	return filepath.Dir(file)
+}
+
+// getGoroutineID returns the id of the current goroutine, which is read from the header of its stack trace
+// in the form of "goroutine 1 [running]:".
+func getGoroutineID() uint64 {
+	buffer := make([]byte, 64)
+	buffer = buffer[:runtime.Stack(buffer, false)]
+	fields := strings.Fields(string(buffer))
+	if len(fields) < 2 {
+		return 0
+	}
+
+	goroutineID, _ := strconv.ParseUint(fields[1], 10, 64)
+	return goroutineID
+}
+
+// getCallerLocation returns the location of the first caller outside this package
+// in the form of "file:line".
+func getCallerLocation() string {
+	programCounters := make([]uintptr, 32)
+	count := runtime.Callers(2, programCounters)
+	frames := runtime.CallersFrames(programCounters[:count])
+	for {
+		frame, more := frames.Next()
+		if frame.File != "" && !isPackageFile(frame.File) {
+			return frame.File + ":" + strconv.Itoa(frame.Line)
+		}
+		if !more {
+			break
+		}
+	}
+	return "unknown"
+}
+
+func isPackageFile(file string) bool {
+	if strings.HasPrefix(file, "<autogenerated>") {
+		return true
+	}
+	return filepath.Dir(file) == packageDirectory && !strings.HasSuffix(file, "_test.go")
+}
+
+func invokePeaMethod(ctx context.Context, obj interface{}, methodName string) error {
+	typ, err := getInstanceType(obj)
+	if err != nil {
+		return err
+	}
+
+	if !typ.IsStruct() {
+		return errors.New("method '" + methodName + "' cannot be invoked, pea is not an instance of struct")
+	}
+
+	for _, method := range typ.ToStructType().GetStructMethods() {
+		if method.GetName() != methodName {
+			continue
+		}
+
+		var results []interface{}
+		parameterTypes := method.GetMethodParameterTypes()
+		if len(parameterTypes) == 1 {
+			results = method.Invoke(obj)
+		} else if len(parameterTypes) == 2 && parameterTypes[1].GetGoType() == contextType {
+			results = method.Invoke(obj, ctx)
+		} else {
+			return errors.New("method '" + methodName + "' must not have any parameter except context")
+		}
+
+		for _, result := range results {
+			if err, ok := result.(error); ok && err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	return errors.New("method '" + methodName + "' could not be found")
+}
@@ -40,6 +40,10 @@ func newStructFunctionWithMoreReturnValuesThanOne() (testStruct, error) {
 	return testStruct{}, nil
 }
 
+func newStructFunctionWithTwoValues() (testStruct, int) {
+	return testStruct{}, 0
+}
+
 func TestCreateInstance_WhenIsInvokedWithFunctionNotHavingAnyParameters(t *testing.T) {
 	instance, err := CreateInstance(goo.GetType(newStructFunction), nil)
 	assert.NotNil(t, instance)